@@ -0,0 +1,224 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/koriwi/yazio-cli/internal/api"
+	"github.com/koriwi/yazio-cli/internal/diary"
+	"github.com/koriwi/yazio-cli/internal/models"
+)
+
+const addUsage = "usage: yazio-cli add --product <id|query> [--amount 150g | --serving cup [--qty 2]] [--meal M] [--date D] [--dry-run] [--output table|json|tsv]"
+
+// runAdd logs a product without the TUI. The product is an ID or a search
+// query whose first hit is used, and the amount is converted to grams with
+// the product's servings the same way the add meal page does.
+func runAdd(args []string) int {
+	fs := flag.NewFlagSet("add", flag.ContinueOnError)
+	product := fs.String("product", "", "product ID, or a search query whose first hit is used")
+	amountStr := fs.String("amount", "", `amount with an optional unit: 150g, 250ml or "2 cup" (default 100g, or 1 --serving)`)
+	servingName := fs.String("serving", "", "one of the product's servings, e.g. cup")
+	qty := fs.Float64("qty", 0, "number of --serving (default 1)")
+	meal := fs.String("meal", "snack", "meal: "+strings.Join(models.MealTimes, ", "))
+	dateStr := fs.String("date", "", "day to log on: YYYY-MM-DD, today, yesterday or -N (default today)")
+	dryRun := fs.Bool("dry-run", false, "show the entry without adding it")
+	output := outputFlag(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return exitUsage
+	}
+
+	// The product may also be given as arguments: `add banana --amount 120g`
+	ref := strings.TrimSpace(*product)
+	if fs.NArg() > 0 {
+		if ref != "" {
+			fmt.Fprintln(os.Stderr, addUsage)
+			return exitUsage
+		}
+		ref = strings.TrimSpace(strings.Join(fs.Args(), " "))
+	}
+	if ref == "" {
+		fmt.Fprintln(os.Stderr, addUsage)
+		return exitUsage
+	}
+	date, err := diary.ParseDate(*dateStr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	if !slices.Contains(models.MealTimes, *meal) {
+		fmt.Fprintf(os.Stderr, "invalid --meal %q, want one of %s\n", *meal, strings.Join(models.MealTimes, ", "))
+		return exitUsage
+	}
+	amt, err := parseAmount(*amountStr, *servingName, *qty)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	client, err := loadClient()
+	if err != nil {
+		return fail(err)
+	}
+	p, err := resolveProduct(client, ref)
+	if err != nil {
+		return fail(err)
+	}
+	servings := models.ProductServings(p)
+	s, ok := findServing(servings, amt.unit)
+	if !ok {
+		var names []string
+		for _, s := range servings {
+			names = append(names, models.ServingLabel(s))
+		}
+		fmt.Fprintf(os.Stderr, "%s has no serving %q; it has: %s\n", p.Name, amt.unit, strings.Join(names, ", "))
+		return exitUsage
+	}
+	n := amt.qty
+	if n == 0 {
+		n, _ = strconv.ParseFloat(models.DefaultServingAmount(s), 64)
+	}
+
+	req := models.AddConsumedRequest{
+		ID:              api.NewUUID(),
+		ProductID:       p.ID,
+		Date:            date.Format(time.DateOnly),
+		Daytime:         *meal,
+		Amount:          models.ServingGrams(n, s),
+		Serving:         s.Serving,
+		ServingQuantity: n,
+		Type:            "product",
+	}
+	if !*dryRun {
+		if err := client.AddConsumedItem(req); err != nil {
+			return fail(err)
+		}
+	}
+	entry := diary.NewEntry(p, req)
+
+	switch *output {
+	case outputJSON:
+		writeJSON(addOutput{Date: req.Date, DryRun: *dryRun, Entry: entry})
+	case outputTSV:
+		t := newTSV(entryTSVHeader...)
+		t.row(entryTSVRow(req.Date, entry)...)
+	default:
+		verb := "added"
+		if *dryRun {
+			verb = "would add (dry run)"
+		}
+		fmt.Printf("%s to %s on %s:\n", verb, models.MealTimeLabel(*meal), date.Format("Mon Jan 2 2006"))
+		amountCell := models.FormatServing(entry.Amount, entry.Serving, entry.ServingQuantity)
+		if s.Serving != "gram" {
+			amountCell += fmt.Sprintf(" (%.0fg)", entry.Amount)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(w, "  %s\t%s\t%s\n", entry.Name, amountCell, nutrientCells(entry.Kcal, entry.Protein, entry.Carbs, entry.Fat))
+		w.Flush()
+		if !*dryRun {
+			fmt.Printf("entry id %s\n", entry.ConsumedID)
+		}
+	}
+	return exitOK
+}
+
+// addOutput is the JSON schema of `add --output json`.
+type addOutput struct {
+	Date   string            `json:"date"`
+	DryRun bool              `json:"dry_run"`
+	Entry  models.DiaryEntry `json:"entry"`
+}
+
+// amount is a parsed --amount/--serving/--qty combination. unit is a serving
+// name ("" = grams) and qty 0 means the serving's default quantity.
+type amount struct {
+	qty  float64
+	unit string
+}
+
+var amountPattern = regexp.MustCompile(`^(\d+(?:[.,]\d+)?)\s*(.*)$`)
+
+func parseAmount(value, serving string, qty float64) (amount, error) {
+	if qty < 0 {
+		return amount{}, fmt.Errorf("--qty must be positive")
+	}
+	if qty > 0 && value != "" {
+		return amount{}, fmt.Errorf("use either --amount or --qty")
+	}
+	a := amount{qty: qty, unit: strings.TrimSpace(serving)}
+	if value == "" {
+		return a, nil
+	}
+	m := amountPattern.FindStringSubmatch(strings.TrimSpace(value))
+	if m == nil {
+		return amount{}, fmt.Errorf("invalid --amount %q, want e.g. 150g or \"2 cup\"", value)
+	}
+	a.qty, _ = strconv.ParseFloat(strings.Replace(m[1], ",", ".", 1), 64)
+	if a.qty <= 0 {
+		return amount{}, fmt.Errorf("--amount must be positive")
+	}
+	if unit := strings.TrimSpace(m[2]); unit != "" {
+		if a.unit != "" {
+			return amount{}, fmt.Errorf("--amount has a unit, so --serving isn't needed")
+		}
+		a.unit = unit
+	}
+	return a, nil
+}
+
+// findServing returns the serving called unit. Grams are "", g, gram(s) or ml
+// (for liquids the base unit is ml); other names also match their plural.
+func findServing(servings []models.Serving, unit string) (models.Serving, bool) {
+	unit = strings.ToLower(unit)
+	switch unit {
+	case "", "g", "gram", "grams", "ml":
+		unit = "gram"
+	}
+	for _, s := range servings {
+		name := strings.ToLower(s.Serving)
+		if unit == name || unit == name+"s" || unit == name+"es" {
+			return s, true
+		}
+	}
+	return models.Serving{}, false
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// resolveProduct loads the product with ID ref, or the first search result
+// for ref when it isn't an ID.
+func resolveProduct(client *api.Client, ref string) (*models.ProductResponse, error) {
+	if uuidPattern.MatchString(ref) {
+		p, err := client.GetProduct(ref)
+		if err != nil {
+			return nil, fmt.Errorf("product %s: %w", ref, err)
+		}
+		return p, nil
+	}
+	profile, err := client.GetProfile()
+	if err != nil {
+		return nil, fmt.Errorf("profile: %w", err)
+	}
+	req := models.NewSearchRequest(ref, *profile)
+	req.Limit = 1
+	hits, err := client.SearchProducts(req)
+	if err != nil {
+		return nil, err
+	}
+	if len(hits) == 0 {
+		return nil, fmt.Errorf("no product found for %q", ref)
+	}
+	// Search results don't include the servings
+	p, err := client.GetProduct(hits[0].ID)
+	if err != nil {
+		return nil, fmt.Errorf("product %s: %w", hits[0].ID, err)
+	}
+	return p, nil
+}
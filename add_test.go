@@ -0,0 +1,90 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/koriwi/yazio-cli/internal/models"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		value, serving string
+		qty            float64
+		want           amount
+	}{
+		{"", "", 0, amount{}},
+		{"150g", "", 0, amount{150, "g"}},
+		{"150", "", 0, amount{150, ""}},
+		{" 2 cup ", "", 0, amount{2, "cup"}},
+		{"1,5 slices", "", 0, amount{1.5, "slices"}},
+		{"0.5", "cup", 0, amount{0.5, "cup"}},
+		{"", "cup", 2, amount{2, "cup"}},
+		{"", " piece ", 0, amount{0, "piece"}},
+	}
+	for _, tt := range tests {
+		got, err := parseAmount(tt.value, tt.serving, tt.qty)
+		if err != nil {
+			t.Errorf("parseAmount(%q, %q, %g): %v", tt.value, tt.serving, tt.qty, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseAmount(%q, %q, %g) = %+v, want %+v", tt.value, tt.serving, tt.qty, got, tt.want)
+		}
+	}
+
+	errs := []struct {
+		value, serving string
+		qty            float64
+	}{
+		{"150g", "", 2},       // --amount and --qty
+		{"2 cup", "cup", 0},   // unit twice
+		{"", "", -1},          // negative --qty
+		{"0", "", 0},          // nothing
+		{"a cup", "", 0},      // no number
+		{"-2 cup", "", 0},     // negative
+		{"cup 2", "slice", 0}, // number last
+	}
+	for _, tt := range errs {
+		if got, err := parseAmount(tt.value, tt.serving, tt.qty); err == nil {
+			t.Errorf("parseAmount(%q, %q, %g) = %+v, want an error", tt.value, tt.serving, tt.qty, got)
+		}
+	}
+}
+
+func TestFindServing(t *testing.T) {
+	servings := []models.Serving{
+		{Amount: 1, Serving: "gram"},
+		{Amount: 240, Serving: "cup"},
+		{Amount: 30, Serving: "Slice"},
+		{Amount: 12, Serving: "bunch"},
+	}
+	tests := []struct {
+		unit string
+		want string // "" for no match
+	}{
+		{"", "gram"},
+		{"g", "gram"},
+		{"grams", "gram"},
+		{"ml", "gram"},
+		{"cup", "cup"},
+		{"cups", "cup"},
+		{"CUPS", "cup"},
+		{"slice", "Slice"},
+		{"slices", "Slice"},
+		{"bunches", "bunch"},
+		{"kg", ""},
+		{"cupss", ""},
+		{"piece", ""},
+	}
+	for _, tt := range tests {
+		s, ok := findServing(servings, tt.unit)
+		if ok != (tt.want != "") || s.Serving != tt.want {
+			t.Errorf("findServing(%q) = %q, %v, want %q", tt.unit, s.Serving, ok, tt.want)
+		}
+	}
+
+	// Products without a gram serving don't match grams
+	if s, ok := findServing([]models.Serving{{Amount: 50, Serving: "piece"}}, "g"); ok {
+		t.Errorf("findServing(g) = %q without a gram serving", s.Serving)
+	}
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/koriwi/yazio-cli/internal/api"
+)
+
+// command is a non-interactive subcommand.
+type command struct {
+	name    string
+	args    string // shown in the usage line
+	summary string
+	run     func(args []string) int
+}
+
+// commands are the subcommands in the order they are listed in the usage.
+var commands = []command{
+	{"diary", "[--date YYYY-MM-DD] [--ids]", "print a day's entries grouped by meal", runDiary},
+	{"goals", "[--date YYYY-MM-DD]", "print the goals of a day", runGoals},
+	{"totals", "[--from YYYY-MM-DD] [--to YYYY-MM-DD]", "print daily totals of a date range", runTotals},
+	{"search", "[--limit N] <query>", "search the food database", runSearch},
+	{"add", "--product <id|query> [--amount 150g | --serving cup [--qty 2]] [--meal M] [--date D] [--dry-run]", "log a product", runAdd},
+	{"delete", "<entry-id>...", "delete diary entries (ids from `diary --ids`)", runDelete},
+	{"copy", "[<entry-id>... | --meal M] [--date D] --to D|D..D [--as-meal M] [--dry-run]", "copy entries, a meal or a day to other days", runCopy},
+	{"move", "<entry-id>... --meal M [--date D] [--dry-run]", "move entries to another meal", runMove},
+	{"export", "--from D [--to D] [--format csv|json|jsonl] [--out F]", "export diary history with nutrients, resumable", runExport},
+	{"import", "--format mfp|cronometer|generic [--mapping F] [--yes] [--dry-run] FILE", "log foods from another tracker's CSV export", runImport},
+	{"doctor", "api [flags]", "check the live API against yazio-api.yaml", runDoctor},
+	{"fake-server", "[--addr host:port]", "serve an in-memory fake API", runFakeServer},
+}
+
+func findCommand(name string) *command {
+	for i := range commands {
+		if commands[i].name == name {
+			return &commands[i]
+		}
+	}
+	return nil
+}
+
+func printUsage() {
+	out := os.Stderr
+	fmt.Fprintln(out, "usage: yazio-cli [flags]            start the TUI")
+	fmt.Fprintln(out, "       yazio-cli [flags] <command> [args]")
+	fmt.Fprintln(out, "\ncommands:")
+	for _, c := range commands {
+		fmt.Fprintf(out, "  %-12s %s\n", c.name, c.summary)
+		fmt.Fprintf(out, "  %-12s   yazio-cli %s %s\n", "", c.name, c.args)
+	}
+	fmt.Fprintln(out, "\ndiary, goals, totals, search, add, copy and move take --output table|json|tsv.")
+	fmt.Fprintln(out, "exit codes: 0 ok, 1 error, 2 usage, 3 not logged in or session expired, 4 API error")
+	fmt.Fprintln(out, "\nflags:")
+}
+
+// parseFlags parses args like fs.Parse but also accepts flags after the
+// positional arguments, e.g. `search banana --limit 5`.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	var flags, positional []string
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		if a == "--" {
+			positional = append(positional, args[i+1:]...)
+			break
+		}
+		if len(a) < 2 || a[0] != '-' {
+			positional = append(positional, a)
+			continue
+		}
+		flags = append(flags, a)
+		name := strings.TrimLeft(a, "-")
+		if strings.Contains(name, "=") {
+			continue
+		}
+		// A non-boolean flag takes the next argument as its value
+		if f := fs.Lookup(name); f != nil && i+1 < len(args) {
+			if b, ok := f.Value.(interface{ IsBoolFlag() bool }); !ok || !b.IsBoolFlag() {
+				i++
+				flags = append(flags, args[i])
+			}
+		}
+	}
+	return fs.Parse(append(append(flags, "--"), positional...))
+}
+
+// Exit codes of the subcommands. They are part of the documented interface
+// for scripts, so don't renumber them.
+const (
+	exitOK    = 0
+	exitError = 1 // anything else, e.g. an unreadable config
+	exitUsage = 2 // invalid flags or arguments
+	exitAuth  = 3 // not logged in, or the session expired
+	exitAPI   = 4 // the API failed or couldn't be reached
+)
+
+// fail reports err on stderr and returns the exit code for it.
+func fail(err error) int {
+	var httpErr *api.HTTPError
+	var netErr net.Error
+	switch {
+	case errors.Is(err, api.ErrSessionExpired):
+		fmt.Fprintln(os.Stderr, "session expired — log in through the app again")
+		return exitAuth
+	case errors.Is(err, errNotLoggedIn):
+		fmt.Fprintln(os.Stderr, err)
+		return exitAuth
+	case errors.As(err, &httpErr):
+		fmt.Fprintln(os.Stderr, err)
+		if httpErr.Status == http.StatusUnauthorized || httpErr.Status == http.StatusForbidden {
+			return exitAuth
+		}
+		return exitAPI
+	case errors.As(err, &netErr):
+		fmt.Fprintln(os.Stderr, err)
+		return exitAPI
+	}
+	fmt.Fprintln(os.Stderr, err)
+	return exitError
+}
+
+// outputFormat is the value of a command's --output flag.
+type outputFormat string
+
+const (
+	outputTable outputFormat = "table"
+	outputJSON  outputFormat = "json"
+	outputTSV   outputFormat = "tsv"
+)
+
+func (o *outputFormat) String() string { return string(*o) }
+
+func (o *outputFormat) Set(s string) error {
+	switch f := outputFormat(s); f {
+	case outputTable, outputJSON, outputTSV:
+		*o = f
+		return nil
+	}
+	return fmt.Errorf("want table, json or tsv")
+}
+
+// outputFlag registers --output (and -o) on fs, defaulting to table.
+func outputFlag(fs *flag.FlagSet) *outputFormat {
+	o := outputTable
+	fs.Var(&o, "output", "output format: table, json or tsv")
+	fs.Var(&o, "o", "shorthand for --output")
+	return &o
+}
+
+// writeJSON prints v as indented JSON.
+func writeJSON(v any) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+// tsvWriter prints tab-separated rows. Tabs and newlines inside a field are
+// replaced by spaces so every record stays on one line.
+type tsvWriter struct{ w io.Writer }
+
+func newTSV(header ...string) tsvWriter {
+	t := tsvWriter{os.Stdout}
+	t.row(header...)
+	return t
+}
+
+func (t tsvWriter) row(fields ...string) {
+	clean := strings.NewReplacer("\t", " ", "\n", " ", "\r", " ")
+	cells := make([]string, len(fields))
+	for i, f := range fields {
+		cells[i] = clean.Replace(f)
+	}
+	fmt.Fprintln(t.w, strings.Join(cells, "\t"))
+}
+
+// num formats a number for TSV output without rounding.
+func num(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
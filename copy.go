@@ -0,0 +1,154 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/koriwi/yazio-cli/internal/api"
+	"github.com/koriwi/yazio-cli/internal/diary"
+	"github.com/koriwi/yazio-cli/internal/models"
+)
+
+const copyUsage = "usage: yazio-cli copy [<entry-id>... | --meal M] [--date D] --to D|D..D [--as-meal M] [--dry-run] [--output table|json|tsv]"
+
+// runCopy logs entries of one day again on other days: the given entries, a
+// meal, or the whole day. The copies are new entries with their own ids.
+func runCopy(args []string) int {
+	fs := flag.NewFlagSet("copy", flag.ContinueOnError)
+	dateStr := fs.String("date", "", "day to copy from (default today)")
+	meal := fs.String("meal", "", "copy only this meal: "+strings.Join(models.MealTimes, ", "))
+	toStr := fs.String("to", "", "day or range to copy to, e.g. tomorrow, 2024-01-16 or +1..+7 (required)")
+	asMeal := fs.String("as-meal", "", "log the copies as this meal (default the meal they're in)")
+	dryRun := fs.Bool("dry-run", false, "show the copies without adding them")
+	output := outputFlag(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return exitUsage
+	}
+	ids := fs.Args()
+	if *toStr == "" || (len(ids) > 0 && *meal != "") {
+		fmt.Fprintln(os.Stderr, copyUsage)
+		return exitUsage
+	}
+	for _, m := range []string{*meal, *asMeal} {
+		if m != "" && !slices.Contains(models.MealTimes, m) {
+			fmt.Fprintf(os.Stderr, "invalid meal %q, want one of %s\n", m, strings.Join(models.MealTimes, ", "))
+			return exitUsage
+		}
+	}
+	date, err := diary.ParseDate(*dateStr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	targets, err := diary.ParseDateRange(*toStr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid --to: %v\n", err)
+		return exitUsage
+	}
+
+	client, err := loadClient()
+	if err != nil {
+		return fail(err)
+	}
+	entries, err := sourceEntries(client, date, ids, *meal)
+	if err != nil {
+		return fail(err)
+	}
+	if len(entries) == 0 {
+		fmt.Fprintf(os.Stderr, "nothing to copy on %s\n", date.Format(time.DateOnly))
+		return exitError
+	}
+
+	reqs := diary.CopyRequests(entries, targets, *asMeal)
+	if !*dryRun {
+		if added, err := diary.AddAll(client, reqs); err != nil {
+			fmt.Fprintf(os.Stderr, "copied %d of %d items\n", added, len(reqs))
+			return fail(err)
+		}
+	}
+	printCopies(entries, reqs, *dryRun, *output, "copied")
+	return exitOK
+}
+
+// sourceEntries returns the entries of date with the given ids, or of meal,
+// or all of them.
+func sourceEntries(client *api.Client, date time.Time, ids []string, meal string) ([]models.DiaryEntry, error) {
+	consumed, err := client.GetConsumedItems(date)
+	if err != nil {
+		return nil, err
+	}
+	all, err := diary.ResolveEntries(consumed, client, &sync.Map{})
+	if err != nil {
+		return nil, err
+	}
+	var entries []models.DiaryEntry
+	switch {
+	case len(ids) > 0:
+		for _, id := range ids {
+			i := slices.IndexFunc(all, func(e models.DiaryEntry) bool { return e.ConsumedID == id })
+			if i < 0 {
+				return nil, fmt.Errorf("no entry %s on %s", id, date.Format(time.DateOnly))
+			}
+			entries = append(entries, all[i])
+		}
+	case meal != "":
+		for _, e := range all {
+			if e.MealTime == meal {
+				entries = append(entries, e)
+			}
+		}
+	default:
+		entries = all
+	}
+	return entries, nil
+}
+
+// copyOutput is the JSON schema of `copy --output json` and `move --output json`.
+type copyOutput struct {
+	DryRun  bool         `json:"dry_run"`
+	Entries []datedEntry `json:"entries"`
+}
+
+// datedEntry is an entry with the day it is logged on.
+type datedEntry struct {
+	Date string `json:"date"`
+	models.DiaryEntry
+}
+
+// printCopies prints the entries that reqs log, made from entries by
+// diary.CopyRequests. verb says what happened, e.g. "copied".
+func printCopies(entries []models.DiaryEntry, reqs []models.AddConsumedRequest, dryRun bool, output outputFormat, verb string) {
+	copies := make([]datedEntry, len(reqs))
+	for i, req := range reqs {
+		e := entries[i%len(entries)]
+		e.ConsumedID, e.MealTime = req.ID, req.Daytime
+		copies[i] = datedEntry{Date: req.Date, DiaryEntry: e}
+	}
+
+	switch output {
+	case outputJSON:
+		writeJSON(copyOutput{DryRun: dryRun, Entries: copies})
+	case outputTSV:
+		t := newTSV(entryTSVHeader...)
+		for _, c := range copies {
+			t.row(entryTSVRow(c.Date, c.DiaryEntry)...)
+		}
+	default:
+		if dryRun {
+			verb = "would be " + verb + " (dry run)"
+		}
+		fmt.Printf("%d items %s:\n", len(copies), verb)
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, c := range copies {
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n", c.Date, models.MealTimeLabel(c.MealTime), c.Name,
+				models.FormatServing(c.Amount, c.Serving, c.ServingQuantity), nutrientCells(c.Kcal, c.Protein, c.Carbs, c.Fat))
+		}
+		w.Flush()
+	}
+}
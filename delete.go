@@ -0,0 +1,32 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// runDelete deletes diary entries by their consumed item ids. It stops at the
+// first failure.
+func runDelete(args []string) int {
+	fs := flag.NewFlagSet("delete", flag.ContinueOnError)
+	if err := parseFlags(fs, args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: yazio-cli delete <entry-id>...")
+		return exitUsage
+	}
+
+	client, err := loadClient()
+	if err != nil {
+		return fail(err)
+	}
+	for _, id := range fs.Args() {
+		if err := client.DeleteConsumedItem(id); err != nil {
+			return fail(fmt.Errorf("delete %s: %w", id, err))
+		}
+		fmt.Printf("deleted %s\n", id)
+	}
+	return exitOK
+}
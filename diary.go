@@ -0,0 +1,133 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/koriwi/yazio-cli/internal/diary"
+	"github.com/koriwi/yazio-cli/internal/models"
+)
+
+// runDiary prints the entries of a day grouped by meal, with a subtotal per
+// meal and the day's totals against the goals.
+func runDiary(args []string) int {
+	fs := flag.NewFlagSet("diary", flag.ContinueOnError)
+	dateStr := fs.String("date", "", "day to show (default today)")
+	ids := fs.Bool("ids", false, "show entry ids, e.g. for delete (table output)")
+	output := outputFlag(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "usage: yazio-cli diary [--date YYYY-MM-DD] [--ids] [--output table|json|tsv]")
+		return exitUsage
+	}
+	date, err := diary.ParseDate(*dateStr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	client, err := loadClient()
+	if err != nil {
+		return fail(err)
+	}
+	day, err := diary.Load(client, date, nil)
+	if err != nil {
+		return fail(err)
+	}
+
+	// The API's totals also count entries whose product couldn't be loaded
+	totals := diary.Sum(day.Entries)
+	if day.Totals != nil {
+		totals = *day.Totals
+	}
+	totals.Date = date.Format(time.DateOnly)
+	var goals models.GoalsResponse
+	if day.Goals != nil {
+		goals = *day.Goals
+	}
+
+	switch *output {
+	case outputJSON:
+		entries := day.Entries
+		if entries == nil {
+			entries = []models.DiaryEntry{}
+		}
+		writeJSON(diaryOutput{Date: totals.Date, Entries: entries, Totals: totals, Goals: goals})
+	case outputTSV:
+		t := newTSV(entryTSVHeader...)
+		for _, e := range day.Entries {
+			t.row(entryTSVRow(totals.Date, e)...)
+		}
+	default:
+		printDiary(day, totals, goals, *ids)
+	}
+	return exitOK
+}
+
+// diaryOutput is the JSON schema of `diary --output json`.
+type diaryOutput struct {
+	Date    string               `json:"date"`
+	Entries []models.DiaryEntry  `json:"entries"`
+	Totals  models.DailyNutrient `json:"totals"`
+	Goals   models.GoalsResponse `json:"goals"`
+}
+
+// entryTSVHeader are the columns of an entry in TSV output.
+var entryTSVHeader = []string{"date", "meal_time", "consumed_id", "product_id", "name", "amount", "serving", "serving_quantity",
+	"kcal", "protein", "carbs", "fat", "error"}
+
+func entryTSVRow(date string, e models.DiaryEntry) []string {
+	return []string{date, e.MealTime, e.ConsumedID, e.ProductID, e.Name, num(e.Amount), e.Serving, num(e.ServingQuantity),
+		num(e.Kcal), num(e.Protein), num(e.Carbs), num(e.Fat), e.Err}
+}
+
+// printDiary prints the entries grouped by meal, with a subtotal per meal and
+// the totals against the goals.
+func printDiary(day *diary.Day, totals models.DailyNutrient, goals models.GoalsResponse, ids bool) {
+	fmt.Println(day.Date.Format("Monday, January 2 2006"))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, meal := range models.MealTimes {
+		var entries []models.DiaryEntry
+		for _, e := range day.Entries {
+			if e.MealTime == meal {
+				entries = append(entries, e)
+			}
+		}
+		if len(entries) == 0 {
+			continue
+		}
+		sub := diary.Sum(entries)
+		fmt.Fprintln(w, "\t\t\t\t\t")
+		fmt.Fprintf(w, "%s\t\t%s\n", models.MealTimeLabel(meal), nutrientCells(sub.Energy, sub.Protein, sub.Carb, sub.Fat))
+		for _, e := range entries {
+			name := e.Name
+			if e.Err != "" {
+				name = "(unavailable: " + e.Err + ")"
+			}
+			line := fmt.Sprintf("  %s\t%s\t%s", name, models.FormatServing(e.Amount, e.Serving, e.ServingQuantity),
+				nutrientCells(e.Kcal, e.Protein, e.Carbs, e.Fat))
+			if ids {
+				line += "\t" + e.ConsumedID
+			}
+			fmt.Fprintln(w, line)
+		}
+	}
+	w.Flush()
+
+	if len(day.Entries) == 0 {
+		fmt.Println("\nnothing logged")
+	}
+
+	fmt.Printf("\nTotal  %.0f / %.0f kcal  protein %.0f/%.0fg  carbs %.0f/%.0fg  fat %.0f/%.0fg\n",
+		totals.Energy, goals.EnergyKcal, totals.Protein, goals.Protein, totals.Carb, goals.Carb, totals.Fat, goals.Fat)
+}
+
+// nutrientCells formats kcal and macros as tab-separated columns.
+func nutrientCells(kcal, protein, carbs, fat float64) string {
+	return fmt.Sprintf("%.0f kcal\tP %.1fg\tC %.1fg\tF %.1fg", kcal, protein, carbs, fat)
+}
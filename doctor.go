@@ -0,0 +1,188 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/koriwi/yazio-cli/internal/api"
+	"github.com/koriwi/yazio-cli/internal/diary"
+	"github.com/koriwi/yazio-cli/internal/models"
+	"github.com/koriwi/yazio-cli/internal/openapi"
+)
+
+//go:embed yazio-api.yaml
+var embeddedSpec []byte
+
+// apiPrefix is the version prefix the spec's server URL adds to every path.
+const apiPrefix = "/v15"
+
+// runDoctor dispatches `yazio-cli doctor <check>`.
+func runDoctor(args []string) int {
+	if len(args) == 0 || args[0] != "api" {
+		fmt.Fprintln(os.Stderr, "usage: yazio-cli doctor api [--spec yazio-api.yaml] [--date YYYY-MM-DD] [--query banana]")
+		return exitUsage
+	}
+	return runDoctorAPI(args[1:])
+}
+
+// doctorCheck is one read-only request to validate against the spec.
+type doctorCheck struct {
+	specPath string // path as written in the spec
+	path     string // concrete request path without the version prefix
+}
+
+// runDoctorAPI calls the read-only endpoints and reports where the responses
+// differ from yazio-api.yaml: unknown fields, missing fields and type changes.
+// It exits with exitError when any difference is found, and like other
+// commands when a request fails.
+func runDoctorAPI(args []string) int {
+	fs := flag.NewFlagSet("doctor api", flag.ContinueOnError)
+	specFile := fs.String("spec", "", "OpenAPI file to validate against (default: the built-in yazio-api.yaml)")
+	dateStr := fs.String("date", "", "diary date to query (default today)")
+	query := fs.String("query", "banana", "search query to use for /products/search")
+	if err := parseFlags(fs, args); err != nil {
+		return exitUsage
+	}
+	date, err := diary.ParseDate(*dateStr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	data := embeddedSpec
+	if *specFile != "" {
+		if data, err = os.ReadFile(*specFile); err != nil {
+			return fail(err)
+		}
+	}
+	spec, err := openapi.Parse(data)
+	if err != nil {
+		return fail(err)
+	}
+
+	client, err := loadClient()
+	if err != nil {
+		return fail(err)
+	}
+
+	d := date.Format(time.DateOnly)
+	weekAgo := date.AddDate(0, 0, -6).Format(time.DateOnly)
+	checks := []doctorCheck{
+		{"/user", "/user"},
+		{"/user/goals", "/user/goals?date=" + d},
+		{"/user/consumed-items", "/user/consumed-items?date=" + d},
+		{"/user/consumed-items/nutrients-daily", fmt.Sprintf("/user/consumed-items/nutrients-daily?start=%s&end=%s", weekAgo, d)},
+	}
+
+	var profile models.UserProfile
+	var consumed models.ConsumedItemsResponse
+	total := 0
+	failed := false
+	var reqErr error // first request that failed, for the exit code
+
+	run := func(c doctorCheck) []byte {
+		body, status, err := client.Raw("GET", apiPrefix+c.path, "")
+		label := "GET " + c.path
+		if err == nil && (status == http.StatusUnauthorized || status == http.StatusForbidden) {
+			err = &api.HTTPError{Status: status, Body: body}
+		}
+		if err != nil {
+			fmt.Printf("✗ %s\n    request failed: %v\n", label, err)
+			failed = true
+			if reqErr == nil {
+				reqErr = err
+			}
+			return nil
+		}
+		schema, err := spec.ResponseSchema("GET", c.specPath, status)
+		if err != nil {
+			fmt.Printf("✗ %s → %d\n    %v\n", label, status, err)
+			failed = true
+			return nil
+		}
+		validated := []byte(body)
+		if c.specPath == "/products/search" && len(body) > 0 && body[0] == '[' {
+			// The spec documents that search may return a bare array instead
+			// of {"products": [...]}; validate both shapes the same way.
+			validated = []byte(`{"products":` + body + `}`)
+		}
+		issues, err := spec.Validate(schema, validated)
+		if err != nil {
+			fmt.Printf("✗ %s → %d\n    %v\n", label, status, err)
+			failed = true
+			return nil
+		}
+		if len(issues) == 0 {
+			fmt.Printf("✓ %s → %d\n", label, status)
+		} else {
+			fmt.Printf("✗ %s → %d (%d issues)\n", label, status, len(issues))
+			for _, i := range issues {
+				fmt.Printf("    %s\n", i)
+			}
+		}
+		total += len(issues)
+		return []byte(body)
+	}
+
+	for _, c := range checks {
+		body := run(c)
+		switch c.specPath {
+		case "/user":
+			json.Unmarshal(body, &profile)
+		case "/user/consumed-items":
+			json.Unmarshal(body, &consumed)
+		}
+	}
+
+	// Search in the user's own database, then look up a product and a
+	// recipe that actually exist so the detail endpoints can be checked.
+	req := models.NewSearchRequest(*query, profile)
+	q := url.Values{}
+	q.Set("query", req.Query)
+	q.Set("language", req.Language)
+	q.Set("countries", req.Country)
+	q.Set("sex", req.Sex)
+	body := run(doctorCheck{"/products/search", "/products/search?" + q.Encode()})
+
+	productID := ""
+	if len(consumed.Products) > 0 {
+		productID = consumed.Products[0].ProductID
+	} else if len(body) > 0 {
+		var results []models.ProductResponse
+		if err := json.Unmarshal(body, &results); err != nil {
+			var obj models.ProductSearchResponse
+			json.Unmarshal(body, &obj)
+			results = obj.Products
+		}
+		if len(results) > 0 {
+			productID = results[0].ID
+		}
+	}
+	if productID != "" {
+		run(doctorCheck{"/products/{productId}", "/products/" + productID})
+	} else {
+		fmt.Println("- GET /products/{productId} skipped: no product found to look up")
+	}
+	if len(consumed.RecipePortions) > 0 {
+		run(doctorCheck{"/recipes/{recipeId}", "/recipes/" + consumed.RecipePortions[0].RecipeID})
+	} else {
+		fmt.Printf("- GET /recipes/{recipeId} skipped: no recipe logged on %s\n", d)
+	}
+
+	fmt.Println()
+	if total > 0 || failed {
+		fmt.Printf("%d differences from the spec\n", total)
+		if reqErr != nil {
+			return fail(reqErr)
+		}
+		return exitError
+	}
+	fmt.Println("all responses match the spec")
+	return exitOK
+}
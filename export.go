@@ -0,0 +1,455 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/koriwi/yazio-cli/internal/api"
+	"github.com/koriwi/yazio-cli/internal/diary"
+	"github.com/koriwi/yazio-cli/internal/models"
+)
+
+const exportUsage = "usage: yazio-cli export --from YYYY-MM-DD [--to YYYY-MM-DD] [--format csv|json|jsonl] [--out FILE] [--totals FILE] [--all-days] [--restart]"
+
+// exportRow is one consumed item in an export. The JSON tags are the field
+// names in json/jsonl and the CSV header.
+type exportRow struct {
+	Date            string             `json:"date"`
+	MealTime        string             `json:"meal_time"`
+	Type            string             `json:"type"` // product or recipe
+	ConsumedID      string             `json:"consumed_id"`
+	ProductID       string             `json:"product_id"`
+	Name            string             `json:"name"`
+	Producer        string             `json:"producer"`
+	Amount          float64            `json:"amount"` // grams, or portions for recipes
+	Serving         string             `json:"serving"`
+	ServingQuantity float64            `json:"serving_quantity"`
+	Kcal            float64            `json:"kcal"`
+	Protein         float64            `json:"protein"`
+	Carbs           float64            `json:"carbs"`
+	Fat             float64            `json:"fat"`
+	Sugar           float64            `json:"sugar"`
+	Saturated       float64            `json:"saturated"`
+	Salt            float64            `json:"salt"`
+	Micros          map[string]float64 `json:"micronutrients,omitempty"` // by dotted key, e.g. vitamin.c
+	Err             string             `json:"error,omitempty"`
+}
+
+var exportHeader = []string{"date", "meal_time", "type", "consumed_id", "product_id", "name", "producer", "amount", "serving",
+	"serving_quantity", "kcal", "protein", "carbs", "fat", "sugar", "saturated", "salt", "micronutrients", "error"}
+
+func (r exportRow) record() []string {
+	var micros []string
+	for k, v := range r.Micros {
+		micros = append(micros, k+"="+num(v))
+	}
+	sort.Strings(micros)
+	return []string{r.Date, r.MealTime, r.Type, r.ConsumedID, r.ProductID, r.Name, r.Producer, num(r.Amount), r.Serving,
+		num(r.ServingQuantity), num(r.Kcal), num(r.Protein), num(r.Carbs), num(r.Fat), num(r.Sugar), num(r.Saturated),
+		num(r.Salt), strings.Join(micros, ";"), r.Err}
+}
+
+// exportTotal is one day of the --totals file.
+type exportTotal struct {
+	Date      string  `json:"date"`
+	Entries   int     `json:"entries"`
+	Kcal      float64 `json:"kcal"`
+	Protein   float64 `json:"protein"`
+	Carbs     float64 `json:"carbs"`
+	Fat       float64 `json:"fat"`
+	Sugar     float64 `json:"sugar"`
+	Saturated float64 `json:"saturated"`
+	Salt      float64 `json:"salt"`
+}
+
+var exportTotalHeader = []string{"date", "entries", "kcal", "protein", "carbs", "fat", "sugar", "saturated", "salt"}
+
+func (t exportTotal) record() []string {
+	return []string{t.Date, strconv.Itoa(t.Entries), num(t.Kcal), num(t.Protein), num(t.Carbs), num(t.Fat),
+		num(t.Sugar), num(t.Saturated), num(t.Salt)}
+}
+
+// exportProgress is saved next to the output after every day, so an
+// interrupted export continues where it stopped. The offsets are where the
+// output files end after the last complete day.
+type exportProgress struct {
+	From         string `json:"from"`
+	To           string `json:"to"`
+	Format       string `json:"format"`
+	Totals       string `json:"totals"`
+	Next         string `json:"next"` // first day not exported yet
+	Offset       int64  `json:"offset"`
+	Rows         int    `json:"rows"`
+	TotalsOffset int64  `json:"totals_offset"`
+	TotalsRows   int    `json:"totals_rows"`
+}
+
+// runExport writes every consumed item from --from to --to, resolved with
+// names and nutrients, and optionally per-day totals. With --out it saves its
+// progress, and running the same command again resumes the export.
+func runExport(args []string) int {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fromStr := fs.String("from", "", "first day (required)")
+	toStr := fs.String("to", "", "last day (default today)")
+	format := fs.String("format", "csv", "csv, json or jsonl")
+	out := fs.String("out", "", "output file (default stdout; needed to resume)")
+	totalsPath := fs.String("totals", "", "also write per-day totals to this file")
+	allDays := fs.Bool("all-days", false, "fetch every day, not just days with calories in the daily totals (slower, also finds days with only zero-calorie items)")
+	restart := fs.Bool("restart", false, "ignore saved progress and start over")
+	if err := parseFlags(fs, args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() > 0 || *fromStr == "" {
+		fmt.Fprintln(os.Stderr, exportUsage)
+		return exitUsage
+	}
+	switch *format {
+	case "csv", "json", "jsonl":
+	default:
+		fmt.Fprintf(os.Stderr, "invalid --format %q, want csv, json or jsonl\n", *format)
+		return exitUsage
+	}
+	from, err := diary.ParseDate(*fromStr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	to, err := diary.ParseDate(*toStr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	if from.After(to) {
+		fmt.Fprintln(os.Stderr, "--from is after --to")
+		return exitUsage
+	}
+	if *totalsPath != "" && *totalsPath == *out {
+		fmt.Fprintln(os.Stderr, "--totals must be a different file than --out")
+		return exitUsage
+	}
+
+	client, err := loadClient()
+	if err != nil {
+		return fail(err)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	client = client.WithContext(ctx)
+
+	progress := exportProgress{
+		From:   from.Format(time.DateOnly),
+		To:     to.Format(time.DateOnly),
+		Format: *format,
+		Totals: *totalsPath,
+		Next:   from.Format(time.DateOnly),
+	}
+	progressPath := ""
+	if *out != "" {
+		progressPath = *out + ".progress"
+		if saved, ok := loadExportProgress(progressPath); ok && !*restart && saved.sameExport(progress) {
+			progress = saved
+			fmt.Fprintf(os.Stderr, "resuming at %s\n", progress.Next)
+		}
+	}
+
+	rows, err := openExportFile(*out, *format, exportHeader, progress.Offset, progress.Rows)
+	if err != nil {
+		return fail(err)
+	}
+	defer rows.close()
+	var totals *exportFile
+	if *totalsPath != "" {
+		if totals, err = openExportFile(*totalsPath, *format, exportTotalHeader, progress.TotalsOffset, progress.TotalsRows); err != nil {
+			return fail(err)
+		}
+		defer totals.close()
+	}
+
+	next, _ := time.ParseInLocation(time.DateOnly, progress.Next, time.Local)
+	var days []time.Time
+	if *allDays {
+		for d := next; !d.After(to); d = d.AddDate(0, 0, 1) {
+			days = append(days, d)
+		}
+	} else if !next.After(to) {
+		if days, err = diary.LoggedDays(client, next, to); err != nil {
+			return exportFailed(err, progress)
+		}
+	}
+
+	cache := &sync.Map{}
+	for i, d := range days {
+		if progressPath != "" {
+			fmt.Fprintf(os.Stderr, "\rexporting %s (%d/%d days)", d.Format(time.DateOnly), i+1, len(days))
+		}
+		dayRows, err := exportDay(client, d, cache)
+		if err != nil {
+			if progressPath != "" {
+				fmt.Fprintln(os.Stderr)
+			}
+			return exportFailed(err, progress)
+		}
+		for _, r := range dayRows {
+			if err := rows.write(r, r.record()); err != nil {
+				return fail(err)
+			}
+		}
+		if totals != nil && len(dayRows) > 0 {
+			t := sumExportRows(d, dayRows)
+			if err := totals.write(t, t.record()); err != nil {
+				return fail(err)
+			}
+		}
+
+		progress.Next = d.AddDate(0, 0, 1).Format(time.DateOnly)
+		progress.Offset, progress.Rows = rows.offset(), rows.rows
+		if totals != nil {
+			progress.TotalsOffset, progress.TotalsRows = totals.offset(), totals.rows
+		}
+		if progressPath != "" {
+			if err := saveExportProgress(progressPath, progress); err != nil {
+				return fail(err)
+			}
+		}
+	}
+	if progressPath != "" && len(days) > 0 {
+		fmt.Fprintln(os.Stderr)
+	}
+
+	if err := rows.finish(); err != nil {
+		return fail(err)
+	}
+	if totals != nil {
+		if err := totals.finish(); err != nil {
+			return fail(err)
+		}
+	}
+	if progressPath != "" {
+		os.Remove(progressPath)
+	}
+	fmt.Fprintf(os.Stderr, "exported %d items\n", rows.rows)
+	return exitOK
+}
+
+// exportFailed reports an error that stopped the export, and how to resume.
+func exportFailed(err error, p exportProgress) int {
+	if errors.Is(err, context.Canceled) {
+		fmt.Fprintf(os.Stderr, "interrupted before %s; run the same command again to resume\n", p.Next)
+		return exitError
+	}
+	fmt.Fprintf(os.Stderr, "stopped before %s; run the same command again to resume\n", p.Next)
+	return fail(err)
+}
+
+// exportDay resolves the items logged on day into export rows.
+func exportDay(client *api.Client, day time.Time, cache *sync.Map) ([]exportRow, error) {
+	consumed, err := client.GetConsumedItems(day)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := diary.ResolveEntries(consumed, client, cache)
+	if err != nil {
+		return nil, err
+	}
+	rows := make([]exportRow, 0, len(entries))
+	for _, e := range entries {
+		row := exportRow{
+			Date:            day.Format(time.DateOnly),
+			MealTime:        e.MealTime,
+			Type:            "product",
+			ConsumedID:      e.ConsumedID,
+			ProductID:       e.ProductID,
+			Name:            e.Name,
+			Amount:          e.Amount,
+			Serving:         e.Serving,
+			ServingQuantity: e.ServingQuantity,
+			Kcal:            e.Kcal,
+			Protein:         e.Protein,
+			Carbs:           e.Carbs,
+			Fat:             e.Fat,
+			Err:             e.Err,
+		}
+		if e.Recipe {
+			row.Type = "recipe"
+		}
+		if e.Err == "" {
+			// Already cached by ResolveEntries
+			var p *models.ProductResponse
+			if e.Recipe {
+				p, _ = diary.FetchRecipe(e.ProductID, client, cache)
+			} else {
+				p, _ = diary.FetchProduct(e.ProductID, client, cache)
+			}
+			if p != nil {
+				// Amount is grams for products and portions for recipes,
+				// and nutrients are per gram or per portion to match
+				row.Producer = p.Producer
+				row.Sugar = math.Round(p.Nutrients.Sugar*e.Amount*10) / 10
+				row.Saturated = math.Round(p.Nutrients.Saturated*e.Amount*10) / 10
+				row.Salt = math.Round(p.Nutrients.Salt*e.Amount*100) / 100
+				for k, v := range p.Micros {
+					if row.Micros == nil {
+						row.Micros = map[string]float64{}
+					}
+					row.Micros[k] = v * e.Amount
+				}
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func sumExportRows(day time.Time, rows []exportRow) exportTotal {
+	t := exportTotal{Date: day.Format(time.DateOnly), Entries: len(rows)}
+	for _, r := range rows {
+		t.Kcal += r.Kcal
+		t.Protein += r.Protein
+		t.Carbs += r.Carbs
+		t.Fat += r.Fat
+		t.Sugar += r.Sugar
+		t.Saturated += r.Saturated
+		t.Salt += r.Salt
+	}
+	round := func(v float64) float64 { return math.Round(v*100) / 100 }
+	t.Kcal, t.Protein, t.Carbs, t.Fat = round(t.Kcal), round(t.Protein), round(t.Carbs), round(t.Fat)
+	t.Sugar, t.Saturated, t.Salt = round(t.Sugar), round(t.Saturated), round(t.Salt)
+	return t
+}
+
+func (p exportProgress) sameExport(q exportProgress) bool {
+	return p.From == q.From && p.To == q.To && p.Format == q.Format && p.Totals == q.Totals
+}
+
+func loadExportProgress(path string) (exportProgress, bool) {
+	var p exportProgress
+	data, err := os.ReadFile(path)
+	if err != nil || json.Unmarshal(data, &p) != nil {
+		return p, false
+	}
+	return p, true
+}
+
+// saveExportProgress replaces the progress file atomically, so an
+// interruption leaves either the old or the new progress.
+func saveExportProgress(path string, p exportProgress) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// exportFile writes records as CSV, a JSON array or JSON lines.
+type exportFile struct {
+	f      *os.File
+	w      io.Writer
+	format string
+	rows   int // records written, including earlier runs
+}
+
+// openExportFile opens path for writing, or stdout when path is empty. When
+// resuming, offset and rows describe the part written by earlier runs, and
+// anything after offset (an unfinished day) is cut off.
+func openExportFile(path, format string, header []string, offset int64, rows int) (*exportFile, error) {
+	e := &exportFile{w: os.Stdout, format: format, rows: rows}
+	if path != "" {
+		f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+		if err != nil {
+			return nil, err
+		}
+		if err := f.Truncate(offset); err != nil {
+			f.Close()
+			return nil, err
+		}
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			f.Close()
+			return nil, err
+		}
+		e.f, e.w = f, f
+	}
+	if offset == 0 {
+		switch format {
+		case "csv":
+			return e, e.writeCSV(header)
+		case "json":
+			_, err := io.WriteString(e.w, "[")
+			return e, err
+		}
+	}
+	return e, nil
+}
+
+func (e *exportFile) writeCSV(record []string) error {
+	w := csv.NewWriter(e.w)
+	w.Write(record)
+	w.Flush()
+	return w.Error()
+}
+
+func (e *exportFile) write(v any, record []string) error {
+	var err error
+	switch e.format {
+	case "csv":
+		err = e.writeCSV(record)
+	case "json":
+		sep := ","
+		if e.rows == 0 {
+			sep = ""
+		}
+		var data []byte
+		if data, err = json.Marshal(v); err == nil {
+			_, err = fmt.Fprintf(e.w, "%s\n  %s", sep, data)
+		}
+	default:
+		var data []byte
+		if data, err = json.Marshal(v); err == nil {
+			_, err = fmt.Fprintf(e.w, "%s\n", data)
+		}
+	}
+	if err == nil {
+		e.rows++
+	}
+	return err
+}
+
+// offset returns the size written so far, for the progress file.
+func (e *exportFile) offset() int64 {
+	if e.f == nil {
+		return 0
+	}
+	n, _ := e.f.Seek(0, io.SeekCurrent)
+	return n
+}
+
+// finish completes the file once every day has been written.
+func (e *exportFile) finish() error {
+	if e.format == "json" {
+		_, err := io.WriteString(e.w, "\n]\n")
+		return err
+	}
+	return nil
+}
+
+func (e *exportFile) close() {
+	if e.f != nil {
+		e.f.Close()
+	}
+}
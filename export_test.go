@@ -0,0 +1,107 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeExport writes rows to path in one go, the way an uninterrupted
+// export does.
+func writeExport(t *testing.T, path, format string, rows []exportTotal) {
+	t.Helper()
+	e, err := openExportFile(path, format, exportTotalHeader, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.close()
+	for _, r := range rows {
+		if err := e.write(r, r.record()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := e.finish(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExportResume(t *testing.T) {
+	days := []exportTotal{
+		{Date: "2024-01-15", Entries: 3, Kcal: 1850.5, Protein: 90},
+		{Date: "2024-01-16", Entries: 1, Kcal: 420},
+		{Date: "2024-01-17", Entries: 2, Kcal: 2010, Salt: 5.2},
+	}
+	for _, format := range []string{"csv", "json", "jsonl"} {
+		t.Run(format, func(t *testing.T) {
+			dir := t.TempDir()
+			want := filepath.Join(dir, "want")
+			writeExport(t, want, format, days)
+
+			// The first run finishes one day and is interrupted during the next
+			path := filepath.Join(dir, "got")
+			e, err := openExportFile(path, format, exportTotalHeader, 0, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			e.write(days[0], days[0].record())
+			offset, rows := e.offset(), e.rows
+			partial := exportTotal{Date: "2024-01-16", Entries: 99}
+			e.write(partial, partial.record())
+			e.close()
+
+			e, err = openExportFile(path, format, exportTotalHeader, offset, rows)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, r := range days[1:] {
+				if err := e.write(r, r.record()); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := e.finish(); err != nil {
+				t.Fatal(err)
+			}
+			e.close()
+
+			got, _ := os.ReadFile(path)
+			wantData, _ := os.ReadFile(want)
+			if string(got) != string(wantData) {
+				t.Errorf("resumed export:\n%s\nwant:\n%s", got, wantData)
+			}
+		})
+	}
+}
+
+func TestExportProgress(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.csv.progress")
+	if _, ok := loadExportProgress(path); ok {
+		t.Fatal("loaded progress that was never saved")
+	}
+	p := exportProgress{From: "2024-01-01", To: "2024-01-31", Format: "csv", Next: "2024-01-10", Offset: 1234, Rows: 40}
+	if err := saveExportProgress(path, p); err != nil {
+		t.Fatal(err)
+	}
+	got, ok := loadExportProgress(path)
+	if !ok || got != p {
+		t.Fatalf("loaded %+v, want %+v", got, p)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Error("the temporary file was left behind")
+	}
+
+	// Only the same command resumes
+	q := p
+	q.Next, q.Offset = "2024-01-20", 5000
+	if !p.sameExport(q) {
+		t.Error("progress of the same export differs")
+	}
+	q.To = "2024-02-29"
+	if p.sameExport(q) {
+		t.Error("a different range resumes the export")
+	}
+
+	os.WriteFile(path, []byte("{not json"), 0600)
+	if _, ok := loadExportProgress(path); ok {
+		t.Error("loaded a corrupt progress file")
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/koriwi/yazio-cli/internal/fakeserver"
+)
+
+// runFakeServer serves the in-memory fake API until interrupted.
+func runFakeServer(args []string) int {
+	fs := flag.NewFlagSet("fake-server", flag.ContinueOnError)
+	addr := fs.String("addr", "127.0.0.1:8080", "address to listen on")
+	if err := parseFlags(fs, args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "usage: yazio-cli fake-server [--addr host:port]")
+		return exitUsage
+	}
+
+	ln, err := net.Listen("tcp", *addr)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "listen: %v\n", err)
+		return exitError
+	}
+	srv := fakeserver.New()
+
+	fmt.Printf("fake YAZIO API listening on http://%s\n\n", ln.Addr())
+	fmt.Printf("  export YAZIO_BASE_URL=http://%s\n", ln.Addr())
+	fmt.Printf("  login: %s / %s\n\n", fakeserver.DefaultEmail, fakeserver.DefaultPassword)
+	fmt.Println("State is kept in memory only. Press ctrl+c to stop.")
+
+	if err := http.Serve(ln, srv.Handler()); err != nil {
+		fmt.Fprintf(os.Stderr, "serve: %v\n", err)
+		return exitError
+	}
+	return exitOK
+}
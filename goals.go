@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/koriwi/yazio-cli/internal/diary"
+	"github.com/koriwi/yazio-cli/internal/models"
+)
+
+// runGoals prints the nutrition goals that apply on a day.
+func runGoals(args []string) int {
+	fs := flag.NewFlagSet("goals", flag.ContinueOnError)
+	dateStr := fs.String("date", "", "day whose goals to show (default today)")
+	output := outputFlag(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "usage: yazio-cli goals [--date YYYY-MM-DD] [--output table|json|tsv]")
+		return exitUsage
+	}
+	date, err := diary.ParseDate(*dateStr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	client, err := loadClient()
+	if err != nil {
+		return fail(err)
+	}
+	goals, err := client.GetGoals(date)
+	if err != nil {
+		return fail(err)
+	}
+
+	d := date.Format(time.DateOnly)
+	switch *output {
+	case outputJSON:
+		writeJSON(goalsOutput{Date: d, GoalsResponse: goals})
+	case outputTSV:
+		t := newTSV("date", "energy_kcal", "protein", "carb", "fat", "water")
+		t.row(d, num(goals.EnergyKcal), num(goals.Protein), num(goals.Carb), num(goals.Fat), num(goals.Water))
+	default:
+		fmt.Printf("Energy   %.0f kcal\n", goals.EnergyKcal)
+		fmt.Printf("Protein  %.0f g\n", goals.Protein)
+		fmt.Printf("Carbs    %.0f g\n", goals.Carb)
+		fmt.Printf("Fat      %.0f g\n", goals.Fat)
+		fmt.Printf("Water    %.0f ml\n", goals.Water)
+	}
+	return exitOK
+}
+
+// goalsOutput is the JSON schema of `goals --output json`: the goals with
+// the day they apply to.
+type goalsOutput struct {
+	Date string `json:"date"`
+	*models.GoalsResponse
+}
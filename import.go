@@ -0,0 +1,509 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/koriwi/yazio-cli/internal/api"
+	"github.com/koriwi/yazio-cli/internal/auth"
+	"github.com/koriwi/yazio-cli/internal/diary"
+	"github.com/koriwi/yazio-cli/internal/models"
+)
+
+const importUsage = "usage: yazio-cli import --format mfp|cronometer|generic [--mapping FILE] [--yes] [--dry-run] [--batch N] FILE"
+
+// importMappingFile is the default mapping file, next to config.json.
+const importMappingFile = "import-mapping.json"
+
+// importColumns are the accepted header names (lower case) of each field in
+// a format's CSV, in order of preference.
+type importColumns struct {
+	date, meal, food, amount, unit, productID []string
+}
+
+var importFormats = map[string]importColumns{
+	// Food diary exports have one row per food with the serving size as
+	// text, e.g. "1 cup" or "1 container (170g)", and the number of servings.
+	"mfp": {
+		date:   []string{"date"},
+		meal:   []string{"meal"},
+		food:   []string{"food name", "food", "name"},
+		amount: []string{"servings", "quantity", "amount"},
+		unit:   []string{"serving size", "serving", "unit"},
+	},
+	// servings.csv from Cronometer's "Export Data", where Amount is e.g.
+	// "150.00 g" or "1.00 cup".
+	"cronometer": {
+		date:   []string{"day", "date"},
+		meal:   []string{"group", "meal"},
+		food:   []string{"food name", "food"},
+		amount: []string{"amount"},
+	},
+	"generic": {
+		date:      []string{"date"},
+		meal:      []string{"meal"},
+		food:      []string{"food", "name"},
+		amount:    []string{"amount", "quantity"},
+		unit:      []string{"unit"},
+		productID: []string{"product_id"},
+	},
+}
+
+// importRow is one food from the CSV. qty is in unit, "" meaning grams.
+type importRow struct {
+	line      int
+	date      time.Time
+	meal      string
+	food      string
+	qty       float64
+	unit      string
+	productID string
+}
+
+// importMatch is what a food name maps to. The mapping file is a JSON object
+// of these by lower-case food name, and can be edited by hand.
+type importMatch struct {
+	ProductID string             `json:"product_id,omitempty"`
+	Name      string             `json:"name,omitempty"`
+	Skip      bool               `json:"skip,omitempty"`  // don't import this food
+	Units     map[string]float64 `json:"units,omitempty"` // grams per unit the product has no serving for
+}
+
+// runImport logs the foods of another tracker's CSV export. Each food name is
+// matched to a product once, by asking or with the first search result, and
+// the matches are kept in a mapping file for the next import.
+func runImport(args []string) int {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := fs.String("format", "generic", "mfp, cronometer or generic (date,meal,food,amount,unit[,product_id])")
+	mappingPath := fs.String("mapping", "", "food → product mapping file (default "+importMappingFile+" next to the config)")
+	yes := fs.Bool("yes", false, "use the first search result for new foods without asking (the default when stdin isn't a terminal)")
+	dryRun := fs.Bool("dry-run", false, "show what would be imported without adding anything")
+	batch := fs.Int("batch", 50, "items per request")
+	if err := parseFlags(fs, args); err != nil {
+		return exitUsage
+	}
+	cols, ok := importFormats[*format]
+	if fs.NArg() != 1 || !ok || *batch < 1 {
+		if !ok {
+			fmt.Fprintf(os.Stderr, "invalid --format %q, want mfp, cronometer or generic\n", *format)
+		}
+		fmt.Fprintln(os.Stderr, importUsage)
+		return exitUsage
+	}
+
+	rows, skipped, err := readImportRows(fs.Arg(0), cols)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	saved := map[string]*importMatch{}
+	if err := loadImportMapping(*mappingPath, saved); err != nil {
+		return fail(fmt.Errorf("mapping file: %w", err))
+	}
+	// Hand-written keys don't have to be lower case
+	mapping := map[string]*importMatch{}
+	for food, m := range saved {
+		mapping[importKey(food)] = m
+	}
+
+	client, err := loadClient()
+	if err != nil {
+		return fail(err)
+	}
+	im := &importer{client: client, mapping: mapping, misses: map[string]bool{}, cache: &sync.Map{}}
+	profile, err := client.GetProfile()
+	if err != nil {
+		return fail(fmt.Errorf("profile: %w", err))
+	}
+	im.profile = *profile
+	if term.IsTerminal(os.Stdin.Fd()) && !*yes {
+		im.in = bufio.NewReader(os.Stdin)
+	}
+
+	var reqs []models.AddConsumedRequest
+	var entries []models.DiaryEntry
+	var lines []int
+	var foods []string
+	for _, r := range rows {
+		req, p, err := im.request(r)
+		if err == nil && req == nil {
+			skipped = append(skipped, fmt.Sprintf("line %d: %s: skipped in the mapping", r.line, r.food))
+			continue
+		}
+		if errors.Is(err, api.ErrSessionExpired) {
+			im.save(*mappingPath)
+			return fail(err)
+		}
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("line %d: %s: %v", r.line, r.food, err))
+			continue
+		}
+		reqs = append(reqs, *req)
+		entries = append(entries, diary.NewEntry(p, *req))
+		lines = append(lines, r.line)
+		foods = append(foods, r.food)
+	}
+	// Matches are kept even in a dry run, so the real import doesn't ask again
+	if err := im.save(*mappingPath); err != nil {
+		fmt.Fprintf(os.Stderr, "could not save the mapping: %v\n", err)
+	}
+	for _, s := range skipped {
+		fmt.Fprintln(os.Stderr, "skipped "+s)
+	}
+
+	if *dryRun {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "DATE\tMEAL\tFOOD\tPRODUCT\tAMOUNT\tKCAL")
+		for i, e := range entries {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%.0f\n", reqs[i].Date, e.MealTime, foods[i], e.Name,
+				models.FormatServing(e.Amount, e.Serving, e.ServingQuantity), e.Kcal)
+		}
+		w.Flush()
+		fmt.Printf("would import %d items, %d skipped (dry run)\n", len(reqs), len(skipped))
+		return exitOK
+	}
+
+	for start := 0; start < len(reqs); start += *batch {
+		end := min(start+*batch, len(reqs))
+		if err := client.AddConsumedItems(reqs[start:end]); err != nil {
+			fmt.Fprintf(os.Stderr, "\nstopped after %d of %d items; the rows from line %d on were not imported\n", start, len(reqs), lines[start])
+			return fail(err)
+		}
+		fmt.Fprintf(os.Stderr, "\rimported %d/%d", end, len(reqs))
+	}
+	if len(reqs) > 0 {
+		fmt.Fprintln(os.Stderr)
+	}
+	fmt.Printf("imported %d items, %d skipped\n", len(reqs), len(skipped))
+	return exitOK
+}
+
+// readImportRows parses a CSV file with the columns cols. Rows that can't be
+// parsed are returned as messages instead.
+func readImportRows(path string, cols importColumns) ([]importRow, []string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	header, err := r.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", path, err)
+	}
+	index := map[string]int{}
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		if _, ok := index[h]; !ok {
+			index[h] = i
+		}
+	}
+	find := func(names []string) int {
+		for _, n := range names {
+			if i, ok := index[n]; ok {
+				return i
+			}
+		}
+		return -1
+	}
+	dateCol, mealCol, foodCol := find(cols.date), find(cols.meal), find(cols.food)
+	amountCol, unitCol, idCol := find(cols.amount), find(cols.unit), find(cols.productID)
+	for name, i := range map[string]int{"date": dateCol, "food": foodCol, "amount": amountCol} {
+		if i < 0 {
+			return nil, nil, fmt.Errorf("%s: no %s column in the header", path, name)
+		}
+	}
+
+	var rows []importRow
+	var skipped []string
+	for line := 2; ; line++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", path, err)
+		}
+		cell := func(i int) string {
+			if i < 0 || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+		row := importRow{line: line, food: cell(foodCol), meal: importMeal(cell(mealCol)), productID: cell(idCol)}
+		if row.food == "" {
+			continue
+		}
+		if row.date, err = parseImportDate(cell(dateCol)); err != nil {
+			skipped = append(skipped, fmt.Sprintf("line %d: %s: %v", line, row.food, err))
+			continue
+		}
+		if row.qty, row.unit, err = parseImportAmount(cell(amountCol), cell(unitCol)); err != nil {
+			skipped = append(skipped, fmt.Sprintf("line %d: %s: %v", line, row.food, err))
+			continue
+		}
+		rows = append(rows, row)
+	}
+	return rows, skipped, nil
+}
+
+var importDateLayouts = []string{time.DateOnly, "2006/01/02", "01/02/2006", "02.01.2006", "Jan 2, 2006"}
+
+// parseImportDate accepts ISO dates, and the US (MM/DD/YYYY) and German
+// (DD.MM.YYYY) formats spreadsheets tend to turn them into.
+func parseImportDate(s string) (time.Time, error) {
+	for _, layout := range importDateLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", s)
+}
+
+// parseImportAmount combines an amount ("2", "150.00 g") with an optional
+// unit ("cup", "1 cup", "1 container (170g)") into a quantity and unit.
+func parseImportAmount(amountCell, unitCell string) (float64, string, error) {
+	m := amountPattern.FindStringSubmatch(amountCell)
+	if m == nil {
+		return 0, "", fmt.Errorf("invalid amount %q", amountCell)
+	}
+	qty, _ := strconv.ParseFloat(strings.Replace(m[1], ",", ".", 1), 64)
+	if qty <= 0 {
+		return 0, "", fmt.Errorf("invalid amount %q", amountCell)
+	}
+	unit := strings.TrimSpace(m[2])
+	if unitCell != "" {
+		unit = unitCell
+		if m := amountPattern.FindStringSubmatch(unitCell); m != nil && strings.TrimSpace(m[2]) != "" {
+			// "1 cup" is a serving size; the amount is the number of them
+			n, _ := strconv.ParseFloat(strings.Replace(m[1], ",", ".", 1), 64)
+			if n > 0 {
+				qty *= n
+				unit = strings.TrimSpace(m[2])
+			}
+		}
+	}
+	return qty, strings.ToLower(unit), nil
+}
+
+// importMeal maps another tracker's meal name to one of models.MealTimes.
+// Anything unknown (Snacks, Uncategorized, custom meals) is a snack.
+func importMeal(name string) string {
+	name = strings.ToLower(name)
+	switch {
+	case strings.Contains(name, "breakfast"), strings.Contains(name, "frühstück"):
+		return "breakfast"
+	case strings.Contains(name, "lunch"), strings.Contains(name, "mittag"):
+		return "lunch"
+	case strings.Contains(name, "dinner"), strings.Contains(name, "supper"), strings.Contains(name, "abend"):
+		return "dinner"
+	}
+	return "snack"
+}
+
+// importUnits are grams per unit for units that don't need the product.
+// Milliliters count as grams, as they do for YAZIO's liquids.
+var importUnits = map[string]float64{
+	"": 1, "g": 1, "gram": 1, "grams": 1, "kg": 1000, "mg": 0.001,
+	"oz": 28.3495, "ounce": 28.3495, "ounces": 28.3495, "lb": 453.592, "lbs": 453.592, "pound": 453.592, "pounds": 453.592,
+	"ml": 1, "l": 1000, "dl": 100, "cl": 10, "fl oz": 29.5735,
+}
+
+// servingGramsPattern finds the weight in serving sizes like "1 container (170g)".
+var servingGramsPattern = regexp.MustCompile(`\((\d+(?:[.,]\d+)?)\s*(?:g|ml)\)`)
+
+// importer turns rows into requests, matching foods to products.
+type importer struct {
+	client  *api.Client
+	profile models.UserProfile
+	mapping map[string]*importMatch
+	changed bool
+	misses  map[string]bool // foods without search results, not saved
+	cache   *sync.Map
+	in      *bufio.Reader // nil when not asking
+}
+
+func importKey(food string) string {
+	return strings.Join(strings.Fields(strings.ToLower(food)), " ")
+}
+
+// request returns the request that logs r, or nil when the food is skipped.
+func (im *importer) request(r importRow) (*models.AddConsumedRequest, *models.ProductResponse, error) {
+	productID := r.productID
+	m := im.mapping[importKey(r.food)]
+	if productID == "" {
+		var err error
+		if m, err = im.match(r); err != nil || m.Skip {
+			return nil, nil, err
+		}
+		productID = m.ProductID
+	}
+	p, err := diary.FetchProduct(productID, im.client, im.cache)
+	if err != nil {
+		return nil, nil, fmt.Errorf("product %s: %w", productID, err)
+	}
+
+	req := &models.AddConsumedRequest{
+		ID:        api.NewUUID(),
+		ProductID: p.ID,
+		Date:      r.date.Format(time.DateOnly),
+		Daytime:   r.meal,
+		Type:      "product",
+	}
+	if g, ok := importUnits[r.unit]; ok {
+		req.Amount, req.Serving, req.ServingQuantity = r.qty*g, "gram", r.qty*g
+	} else if s, ok := findServing(models.ProductServings(p), r.unit); ok {
+		req.Amount, req.Serving, req.ServingQuantity = models.ServingGrams(r.qty, s), s.Serving, r.qty
+	} else if g, err := im.unitGrams(r, m, p); err == nil {
+		req.Amount, req.Serving, req.ServingQuantity = r.qty*g, "gram", r.qty*g
+	} else {
+		return nil, nil, err
+	}
+	return req, p, nil
+}
+
+// match returns the mapping of r's food, searching for a product and asking
+// which one it is if it isn't mapped yet.
+func (im *importer) match(r importRow) (*importMatch, error) {
+	key := importKey(r.food)
+	if m, ok := im.mapping[key]; ok {
+		return m, nil
+	}
+	if im.misses[key] {
+		return nil, fmt.Errorf("no product found")
+	}
+	query := r.food
+	if im.in != nil {
+		fmt.Fprintf(os.Stderr, "\n%q (line %d):\n", r.food, r.line)
+	}
+	for {
+		req := models.NewSearchRequest(query, im.profile)
+		req.Limit = 5
+		hits, err := im.client.SearchProducts(req)
+		if err != nil {
+			return nil, err
+		}
+		if im.in == nil {
+			if len(hits) == 0 {
+				im.misses[key] = true
+				return nil, fmt.Errorf("no product found")
+			}
+			m := &importMatch{ProductID: hits[0].ID, Name: hits[0].Name}
+			im.mapping[key], im.changed = m, true
+			return m, nil
+		}
+
+		for i, h := range hits {
+			name := h.Name
+			if h.Producer != "" {
+				name += " · " + h.Producer
+			}
+			if h.IsVerified {
+				name += " ✓"
+			}
+			fmt.Fprintf(os.Stderr, "  %d  %s  %.0f kcal/100g\n", i+1, name, h.Nutrients.EnergyKcal*100)
+		}
+		if len(hits) == 0 {
+			fmt.Fprintf(os.Stderr, "  no results for %q\n", query)
+			fmt.Fprint(os.Stderr, "[s] skip, or type another search: ")
+		} else {
+			fmt.Fprintf(os.Stderr, "[1-%d] pick, [enter] 1, [s] skip, or type another search: ", len(hits))
+		}
+		answer, err := im.in.ReadString('\n')
+		answer = strings.TrimSpace(answer)
+		if err != nil && answer == "" {
+			return nil, fmt.Errorf("no answer")
+		}
+		var m *importMatch
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(hits) {
+			m = &importMatch{ProductID: hits[n-1].ID, Name: hits[n-1].Name}
+		} else if answer == "" && len(hits) > 0 {
+			m = &importMatch{ProductID: hits[0].ID, Name: hits[0].Name}
+		} else if strings.EqualFold(answer, "s") {
+			m = &importMatch{Skip: true}
+		} else if answer != "" {
+			query = answer
+			continue
+		} else {
+			continue
+		}
+		im.mapping[key], im.changed = m, true
+		return m, nil
+	}
+}
+
+// unitGrams returns the grams in one unit of r's food for units that are
+// neither a weight nor one of the product's servings: from the serving text,
+// the mapping, or by asking.
+func (im *importer) unitGrams(r importRow, m *importMatch, p *models.ProductResponse) (float64, error) {
+	if sm := servingGramsPattern.FindStringSubmatch(r.unit); sm != nil {
+		return strconv.ParseFloat(strings.Replace(sm[1], ",", ".", 1), 64)
+	}
+	if m != nil && m.Units[r.unit] > 0 {
+		return m.Units[r.unit], nil
+	}
+	if im.in == nil || m == nil {
+		return 0, fmt.Errorf("unknown unit %q; add its grams to the mapping's units", r.unit)
+	}
+	for {
+		fmt.Fprintf(os.Stderr, "How many grams is 1 %s of %s? ", r.unit, p.Name)
+		answer, err := im.in.ReadString('\n')
+		answer = strings.TrimSpace(answer)
+		if answer == "" && err != nil {
+			return 0, fmt.Errorf("unknown unit %q", r.unit)
+		}
+		g, perr := strconv.ParseFloat(strings.Replace(answer, ",", ".", 1), 64)
+		if perr != nil || g <= 0 {
+			continue
+		}
+		if m.Units == nil {
+			m.Units = map[string]float64{}
+		}
+		m.Units[r.unit], im.changed = g, true
+		return g, nil
+	}
+}
+
+// save writes the mapping if it changed.
+func (im *importer) save(path string) error {
+	if !im.changed {
+		return nil
+	}
+	if path == "" {
+		return auth.SaveJSON(importMappingFile, im.mapping)
+	}
+	data, err := json.MarshalIndent(im.mapping, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0600)
+}
+
+func loadImportMapping(path string, mapping map[string]*importMatch) error {
+	if path == "" {
+		return auth.LoadJSON(importMappingFile, &mapping)
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, &mapping)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseImportDate(t *testing.T) {
+	want := time.Date(2024, 3, 5, 0, 0, 0, 0, time.Local)
+	for _, in := range []string{"2024-03-05", "2024/03/05", "03/05/2024", "05.03.2024", "Mar 5, 2024"} {
+		got, err := parseImportDate(in)
+		if err != nil {
+			t.Errorf("parseImportDate(%q): %v", in, err)
+			continue
+		}
+		if !got.Equal(want) {
+			t.Errorf("parseImportDate(%q) = %s, want %s", in, got, want)
+		}
+	}
+	for _, in := range []string{"", "5.3.24", "2024-02-30", "13/01/2024", "yesterday"} {
+		if got, err := parseImportDate(in); err == nil {
+			t.Errorf("parseImportDate(%q) = %s, want an error", in, got)
+		}
+	}
+}
+
+func TestParseImportAmount(t *testing.T) {
+	tests := []struct {
+		amount, unit string
+		qty          float64
+		wantUnit     string
+	}{
+		{"150", "", 150, ""},
+		{"150.00 g", "", 150, "g"},
+		{"1,5 kg", "", 1.5, "kg"},
+		{"2", "cup", 2, "cup"},
+		{"2", "Cups", 2, "cups"},
+		{"2", "1 cup", 2, "cup"},
+		{"1.5", "2 slices", 3, "slices"},
+		{"1", "1 container (170g)", 1, "container (170g)"},
+		{"3", "100", 3, "100"}, // a bare number isn't a serving size
+		{"0.5", "fl oz", 0.5, "fl oz"},
+	}
+	for _, tt := range tests {
+		qty, unit, err := parseImportAmount(tt.amount, tt.unit)
+		if err != nil {
+			t.Errorf("parseImportAmount(%q, %q): %v", tt.amount, tt.unit, err)
+			continue
+		}
+		if qty != tt.qty || unit != tt.wantUnit {
+			t.Errorf("parseImportAmount(%q, %q) = %g %q, want %g %q", tt.amount, tt.unit, qty, unit, tt.qty, tt.wantUnit)
+		}
+	}
+	for _, in := range []string{"", "0", "0 g", "-1", "a lot", "g 150"} {
+		if qty, unit, err := parseImportAmount(in, ""); err == nil {
+			t.Errorf("parseImportAmount(%q) = %g %q, want an error", in, qty, unit)
+		}
+	}
+}
+
+func TestImportMeal(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"Breakfast", "breakfast"},
+		{"Frühstück", "breakfast"},
+		{"Lunch", "lunch"},
+		{"Mittagessen", "lunch"},
+		{"Dinner", "dinner"},
+		{"Supper", "dinner"},
+		{"Abendessen", "dinner"},
+		{"Snacks", "snack"},
+		{"Uncategorized", "snack"},
+		{"", "snack"},
+	}
+	for _, tt := range tests {
+		if got := importMeal(tt.in); got != tt.want {
+			t.Errorf("importMeal(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -2,17 +2,23 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"crypto/rand"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"os"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/koriwi/yazio-cli/internal/models"
+	"github.com/koriwi/yazio-cli/internal/redact"
 )
 
 // ErrSessionExpired is returned when the access token is invalid and the refresh token
@@ -20,21 +26,40 @@ import (
 // user to the login screen.
 var ErrSessionExpired = errors.New("session expired")
 
+// HTTPError is returned for a response with a non-2xx status.
+type HTTPError struct {
+	Status int
+	Body   string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("HTTP %d: %s", e.Status, e.Body)
+}
+
 const (
-	base         = "https://yzapi.yazio.com"
-	apiLogin     = "/v15/oauth/token"
-	apiConsumed  = "/v15/user/consumed-items"
-	apiNutrDaily = "/v15/user/consumed-items/nutrients-daily"
-	apiProducts  = "/v15/products"
-	apiGoals     = "/v15/user/goals"
-	apiExercises = "/v15/user/exercises"
-	apiWater     = "/v15/user/water-intake"
-	apiRecipes   = "/v15/recipes"
+	defaultBaseURL = "https://yzapi.yazio.com"
+	apiLogin       = "/v15/oauth/token"
+	apiConsumed    = "/v15/user/consumed-items"
+	apiNutrDaily   = "/v15/user/consumed-items/nutrients-daily"
+	apiProducts    = "/v15/products"
+	apiGoals       = "/v15/user/goals"
+	apiExercises   = "/v15/user/exercises"
+	apiWater       = "/v15/user/water-intake"
+	apiRecipes     = "/v15/recipes"
 
 	defaultClientID     = "1_4hiybetvfksgw40o0sog4s884kwc840wwso8go4k8c04goo4c"
 	defaultClientSecret = "6rok2m65xuskgkgogw40wkkk8sw0osg84s8cggsc4woos4s8o"
 )
 
+// getBaseURL returns the API host. YAZIO_BASE_URL points the client at another
+// server, e.g. `yazio-cli fake-server`.
+func getBaseURL() string {
+	if v := os.Getenv("YAZIO_BASE_URL"); v != "" {
+		return strings.TrimRight(v, "/")
+	}
+	return defaultBaseURL
+}
+
 func getClientID() string {
 	if v := os.Getenv("YAZIO_CLIENT_ID"); v != "" {
 		return v
@@ -50,87 +75,285 @@ func getClientSecret() string {
 }
 
 type Client struct {
-	http         *http.Client
+	http    *http.Client
+	base    string
+	log     *slog.Logger
+	history *History
+	ctx     context.Context
+	session *session
+}
+
+// session holds the tokens. It is shared by every copy made with WithContext,
+// so a refresh done by one copy is seen by all of them.
+type session struct {
+	refreshMu    sync.Mutex // held for a whole token exchange
+	mu           sync.Mutex // guards the fields below
 	token        string
 	refreshToken string
 	onRefresh    func(accessToken, refreshToken string)
 }
 
+func (s *session) accessToken() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.token
+}
+
+// DefaultTransport is the RoundTripper used by clients created with New.
+// nil means http.DefaultTransport. Replace it to record or replay traffic for
+// every client the app creates (including the one used for login).
+var DefaultTransport http.RoundTripper
+
+// DefaultLogger receives request logs from clients created with New.
+// nil disables logging.
+var DefaultLogger *slog.Logger
+
 func New(token string) *Client {
-	return &Client{http: &http.Client{Timeout: 15 * time.Second}, token: token}
+	c := &Client{
+		http:    &http.Client{Timeout: 15 * time.Second, Transport: DefaultTransport},
+		base:    getBaseURL(),
+		ctx:     context.Background(),
+		session: &session{token: token},
+	}
+	c.SetLogger(DefaultLogger)
+	return c
+}
+
+// SetHistory makes the client keep a copy of every call in h (nil to stop).
+func (c *Client) SetHistory(h *History) {
+	c.history = h
+}
+
+// SetLogger sets where request logs go. nil disables logging.
+func (c *Client) SetLogger(l *slog.Logger) {
+	if l == nil {
+		l = slog.New(slog.DiscardHandler)
+	}
+	c.log = l
+}
+
+// SetBaseURL points the client at another API host (scheme and host, without
+// the /v15 prefix), e.g. an httptest server from package fakeserver.
+func (c *Client) SetBaseURL(baseURL string) {
+	c.base = strings.TrimRight(baseURL, "/")
+}
+
+// SetTransport replaces the RoundTripper used for this client's requests,
+// e.g. with a cassette.Replayer in tests.
+func (c *Client) SetTransport(rt http.RoundTripper) {
+	c.http.Transport = rt
 }
 
 // SetRefresh configures the client to automatically refresh the access token on 401
 // responses. onRefresh is called with the new tokens so the caller can persist them.
 func (c *Client) SetRefresh(refreshToken string, onRefresh func(accessToken, refreshToken string)) {
-	c.refreshToken = refreshToken
-	c.onRefresh = onRefresh
+	c.session.mu.Lock()
+	defer c.session.mu.Unlock()
+	c.session.refreshToken = refreshToken
+	c.session.onRefresh = onRefresh
+}
+
+// WithContext returns a copy of the client whose requests are cancelled when
+// ctx is done. The copy shares tokens, history and logger with c.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	c2 := *c
+	c2.ctx = ctx
+	return &c2
 }
 
 // rawRequest executes the HTTP request and returns the body, status code, and any error.
 // It never retries — use request() for automatic 401 handling.
 func (c *Client) rawRequest(method, path string, body []byte) ([]byte, int, error) {
+	data, status, _, err := c.send(method, path, body)
+	return data, status, err
+}
+
+// maxRateLimitRetries is how often a request rejected with 429 Too Many
+// Requests is retried before the 429 is returned.
+const maxRateLimitRetries = 5
+
+// send is rawRequest that also returns the access token the request was sent
+// with, so a 401 can be matched against the token that caused it. Responses
+// with 429 are retried after the delay the server asks for in Retry-After.
+func (c *Client) send(method, path string, body []byte) ([]byte, int, string, error) {
+	for attempt := 0; ; attempt++ {
+		data, status, token, header, err := c.sendOnce(method, path, body)
+		if err != nil || status != http.StatusTooManyRequests || attempt == maxRateLimitRetries {
+			return data, status, token, err
+		}
+		wait := retryAfter(header.Get("Retry-After"), attempt, time.Now())
+		c.log.Warn("rate limited, waiting", "method", method, "path", redact.String(path), "wait", wait.String())
+		select {
+		case <-time.After(wait):
+		case <-c.ctx.Done():
+			return nil, 0, token, c.ctx.Err()
+		}
+	}
+}
+
+// retryAfter returns how long to wait before retrying a 429: the Retry-After
+// header in seconds or as a date if there is one, otherwise an exponential
+// backoff from one second. The wait is capped at a minute.
+func retryAfter(header string, attempt int, now time.Time) time.Duration {
+	wait := time.Second << attempt
+	if secs, err := strconv.Atoi(strings.TrimSpace(header)); err == nil {
+		wait = time.Duration(secs) * time.Second
+	} else if t, err := http.ParseTime(header); err == nil {
+		wait = t.Sub(now)
+	}
+	return min(max(wait, 0), time.Minute)
+}
+
+func (c *Client) sendOnce(method, path string, body []byte) ([]byte, int, string, http.Header, error) {
 	var r io.Reader
 	if body != nil {
 		r = bytes.NewBuffer(body)
 	}
-	req, err := http.NewRequest(method, base+path, r)
+	req, err := http.NewRequestWithContext(c.ctx, method, c.base+path, r)
 	if err != nil {
-		return nil, 0, err
+		return nil, 0, "", nil, err
 	}
-	if c.token != "" {
-		req.Header.Set("Authorization", "Bearer "+c.token)
+	token := c.session.accessToken()
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
 	}
 	if body != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
 
+	start := time.Now()
 	resp, err := c.http.Do(req)
 	if err != nil {
-		return nil, 0, err
+		c.observe(method, path, 0, start, body, nil, err)
+		return nil, 0, token, nil, err
 	}
 	defer resp.Body.Close()
 
 	data, err := io.ReadAll(resp.Body)
+	c.observe(method, path, resp.StatusCode, start, body, data, err)
 	if err != nil {
-		return nil, resp.StatusCode, err
+		return nil, resp.StatusCode, token, resp.Header, err
 	}
-	return data, resp.StatusCode, nil
+	return data, resp.StatusCode, token, resp.Header, nil
+}
+
+// observe writes one log record per HTTP round trip and adds it to the call
+// history. Bodies are only logged at debug level, and always redacted.
+func (c *Client) observe(method, path string, status int, start time.Time, reqBody, respBody []byte, err error) {
+	latency := time.Since(start)
+	if c.history != nil {
+		call := Call{
+			Time:         start,
+			Method:       method,
+			Path:         redact.String(path),
+			Status:       status,
+			Latency:      latency,
+			RequestBody:  string(redact.JSON(reqBody)),
+			ResponseBody: string(redact.JSON(respBody)),
+		}
+		if err != nil {
+			call.Err = redact.String(err.Error())
+		}
+		c.history.add(call)
+	}
+
+	ctx := context.Background()
+	attrs := []slog.Attr{
+		slog.String("method", method),
+		slog.String("path", redact.String(path)),
+		slog.Int("status", status),
+		slog.Float64("latency_ms", float64(latency.Microseconds())/1000),
+		slog.Int("bytes", len(respBody)),
+	}
+	level := slog.LevelInfo
+	switch {
+	case err != nil:
+		level = slog.LevelError
+		attrs = append(attrs, slog.String("error", redact.String(err.Error())))
+	case status >= 400:
+		level = slog.LevelWarn
+	}
+	if c.log.Enabled(ctx, slog.LevelDebug) {
+		attrs = append(attrs,
+			slog.String("request_body", string(redact.JSON(reqBody))),
+			slog.String("response_body", string(redact.JSON(respBody))),
+		)
+	}
+	c.log.LogAttrs(ctx, level, "http request", attrs...)
 }
 
 // request executes the HTTP request, retrying once with a refreshed token on 401.
 func (c *Client) request(method, path string, body []byte) ([]byte, error) {
-	data, status, err := c.rawRequest(method, path, body)
+	data, status, err := c.refreshingRequest(method, path, body)
 	if err != nil {
 		return nil, err
 	}
-	if status == 401 && c.refreshToken != "" {
-		if refreshErr := c.doRefresh(); refreshErr != nil {
-			return nil, fmt.Errorf("HTTP 401: token refresh failed: %w", refreshErr)
-		}
-		data, status, err = c.rawRequest(method, path, body)
-		if err != nil {
-			return nil, err
-		}
-	}
 	if status < 200 || status >= 300 {
-		return nil, fmt.Errorf("HTTP %d: %s", status, string(data))
+		return nil, &HTTPError{Status: status, Body: string(data)}
 	}
 	return data, nil
 }
 
-// doRefresh exchanges c.refreshToken for a new access token and updates the client state.
-func (c *Client) doRefresh() error {
-	resp, err := c.RefreshAccessToken(c.refreshToken)
+// refreshingRequest executes the HTTP request, retrying once with a refreshed token
+// on 401, and returns the final body and status whatever it is.
+func (c *Client) refreshingRequest(method, path string, body []byte) ([]byte, int, error) {
+	data, status, token, err := c.send(method, path, body)
+	if err != nil {
+		return nil, 0, err
+	}
+	if status == 401 && c.canRefresh() {
+		c.log.Info("access token rejected, refreshing", "method", method, "path", redact.String(path))
+		if refreshErr := c.doRefresh(token); refreshErr != nil {
+			c.log.Warn("token refresh failed", "error", redact.String(refreshErr.Error()))
+			return nil, status, fmt.Errorf("HTTP 401: token refresh failed: %w", refreshErr)
+		}
+		c.log.Info("token refreshed, retrying request", "method", method, "path", redact.String(path))
+		return c.rawRequest(method, path, body)
+	}
+	return data, status, nil
+}
+
+// canRefresh reports whether a refresh token was configured with SetRefresh.
+func (c *Client) canRefresh() bool {
+	c.session.mu.Lock()
+	defer c.session.mu.Unlock()
+	return c.session.refreshToken != ""
+}
+
+// doRefresh exchanges the refresh token for a new access token and updates the
+// session. stale is the access token that was rejected: when another request
+// has already replaced it, the refresh is skipped. Refresh tokens rotate, so
+// two concurrent exchanges of the same one would log the user out.
+func (c *Client) doRefresh(stale string) error {
+	s := c.session
+	s.refreshMu.Lock()
+	defer s.refreshMu.Unlock()
+
+	s.mu.Lock()
+	current, refreshToken := s.token, s.refreshToken
+	s.mu.Unlock()
+	if current != stale {
+		return nil
+	}
+
+	resp, err := c.RefreshAccessToken(refreshToken)
 	if err != nil {
+		if ctxErr := c.ctx.Err(); ctxErr != nil {
+			// Cancelled by the caller; the refresh token may still be good.
+			return ctxErr
+		}
 		return fmt.Errorf("%w: %v", ErrSessionExpired, err)
 	}
-	c.token = resp.AccessToken
+
+	s.mu.Lock()
+	s.token = resp.AccessToken
 	if resp.RefreshToken != "" {
-		c.refreshToken = resp.RefreshToken
+		s.refreshToken = resp.RefreshToken
 	}
-	if c.onRefresh != nil {
-		c.onRefresh(c.token, c.refreshToken)
+	accessToken, refreshToken, onRefresh := s.token, s.refreshToken, s.onRefresh
+	s.mu.Unlock()
+	if onRefresh != nil {
+		onRefresh(accessToken, refreshToken)
 	}
 	return nil
 }
@@ -151,7 +374,7 @@ func (c *Client) Login(email, password string) (tokenResponse, error) {
 		return tokenResponse{}, err
 	}
 	if status < 200 || status >= 300 {
-		return tokenResponse{}, fmt.Errorf("HTTP %d: %s", status, string(data))
+		return tokenResponse{}, &HTTPError{Status: status, Body: string(data)}
 	}
 	var resp tokenResponse
 	if err := json.Unmarshal(data, &resp); err != nil {
@@ -174,7 +397,7 @@ func (c *Client) RefreshAccessToken(refreshToken string) (tokenResponse, error)
 		return tokenResponse{}, err
 	}
 	if status < 200 || status >= 300 {
-		return tokenResponse{}, fmt.Errorf("HTTP %d: %s", status, string(data))
+		return tokenResponse{}, &HTTPError{Status: status, Body: string(data)}
 	}
 	var resp tokenResponse
 	if err := json.Unmarshal(data, &resp); err != nil {
@@ -202,8 +425,20 @@ func (c *Client) GetConsumedItems(date time.Time) (*models.ConsumedItemsResponse
 
 // GetDailyNutrients fetches summed nutrients for a single date.
 func (c *Client) GetDailyNutrients(date time.Time) (*models.DailyNutrient, error) {
-	d := date.Format(time.DateOnly)
-	path := fmt.Sprintf("%s?start=%s&end=%s", apiNutrDaily, d, d)
+	entries, err := c.GetDailyNutrientsRange(date, date)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return &models.DailyNutrient{Date: date.Format(time.DateOnly)}, nil
+	}
+	return &entries[0], nil
+}
+
+// GetDailyNutrientsRange fetches the daily totals from start to end, both
+// inclusive, in one request. Days without any logged items may be missing.
+func (c *Client) GetDailyNutrientsRange(start, end time.Time) ([]models.DailyNutrient, error) {
+	path := fmt.Sprintf("%s?start=%s&end=%s", apiNutrDaily, start.Format(time.DateOnly), end.Format(time.DateOnly))
 	data, err := c.request("GET", path, nil)
 	if err != nil {
 		return nil, err
@@ -212,10 +447,7 @@ func (c *Client) GetDailyNutrients(date time.Time) (*models.DailyNutrient, error
 	if err := json.Unmarshal(data, &entries); err != nil {
 		return nil, err
 	}
-	if len(entries) == 0 {
-		return &models.DailyNutrient{Date: d}, nil
-	}
-	return &entries[0], nil
+	return entries, nil
 }
 
 // GetGoals fetches calorie/macro goals for a date.
@@ -280,15 +512,21 @@ func (c *Client) GetProfile() (*models.UserProfile, error) {
 	return &p, nil
 }
 
-// SearchProducts searches for products. country should be the user's country code (e.g. "DE"),
-// sex should be "male" or "female" — both are required by the YAZIO API.
-func (c *Client) SearchProducts(query, country, sex string) ([]models.ProductResponse, error) {
-	path := fmt.Sprintf("%s/search?query=%s&language=en&countries=%s&sex=%s",
-		apiProducts,
-		url.QueryEscape(query),
-		url.QueryEscape(country),
-		url.QueryEscape(sex),
-	)
+// SearchProducts searches for products. Language, Country and Sex are required by the
+// YAZIO API; Limit and Offset are only sent when set, to page through results.
+func (c *Client) SearchProducts(req models.SearchRequest) ([]models.ProductResponse, error) {
+	q := url.Values{}
+	q.Set("query", req.Query)
+	q.Set("language", req.Language)
+	q.Set("countries", req.Country)
+	q.Set("sex", req.Sex)
+	if req.Limit > 0 {
+		q.Set("limit", strconv.Itoa(req.Limit))
+	}
+	if req.Offset > 0 {
+		q.Set("offset", strconv.Itoa(req.Offset))
+	}
+	path := apiProducts + "/search?" + q.Encode()
 	data, err := c.request("GET", path, nil)
 	if err != nil {
 		return nil, err
@@ -305,8 +543,17 @@ func (c *Client) SearchProducts(query, country, sex string) ([]models.ProductRes
 	return obj.Products, nil
 }
 
-// AddConsumedItem posts a new consumed item to the diary.
+// AddConsumedItem posts a new consumed item to the diary. With Type
+// "recipe_portion" the item is logged as ServingQuantity portions of the
+// recipe ProductID; anything else is logged as a product. A request without
+// an ID gets a new random one.
 func (c *Client) AddConsumedItem(req models.AddConsumedRequest) error {
+	return c.AddConsumedItems([]models.AddConsumedRequest{req})
+}
+
+// AddConsumedItems posts several consumed items in one request, each like
+// AddConsumedItem.
+func (c *Client) AddConsumedItems(reqs []models.AddConsumedRequest) error {
 	type productEntry struct {
 		ID              string  `json:"id"`
 		ProductID       string  `json:"product_id"`
@@ -316,24 +563,48 @@ func (c *Client) AddConsumedItem(req models.AddConsumedRequest) error {
 		Serving         string  `json:"serving"`
 		ServingQuantity float64 `json:"serving_quantity"`
 	}
+	type recipeEntry struct {
+		ID           string  `json:"id"`
+		RecipeID     string  `json:"recipe_id"`
+		Date         string  `json:"date"`
+		Daytime      string  `json:"daytime"`
+		PortionCount float64 `json:"portion_count"`
+	}
 	type wrapper struct {
 		Products       []productEntry `json:"products"`
-		RecipePortions []any          `json:"recipe_portions"`
+		RecipePortions []recipeEntry  `json:"recipe_portions"`
 		SimpleProducts []any          `json:"simple_products"`
 	}
 	w := wrapper{
-		Products: []productEntry{{
-			ID:              newUUID(),
-			ProductID:       req.ProductID,
-			Date:            req.Date,
-			Daytime:         req.Daytime,
-			Amount:          req.Amount,
-			Serving:         req.Serving,
-			ServingQuantity: req.ServingQuantity,
-		}},
-		RecipePortions: []any{},
+		Products:       []productEntry{},
+		RecipePortions: []recipeEntry{},
 		SimpleProducts: []any{},
 	}
+	for _, req := range reqs {
+		id := req.ID
+		if id == "" {
+			id = NewUUID()
+		}
+		if req.Type == "recipe_portion" {
+			w.RecipePortions = append(w.RecipePortions, recipeEntry{
+				ID:           id,
+				RecipeID:     req.ProductID,
+				Date:         req.Date,
+				Daytime:      req.Daytime,
+				PortionCount: req.ServingQuantity,
+			})
+		} else {
+			w.Products = append(w.Products, productEntry{
+				ID:              id,
+				ProductID:       req.ProductID,
+				Date:            req.Date,
+				Daytime:         req.Daytime,
+				Amount:          req.Amount,
+				Serving:         req.Serving,
+				ServingQuantity: req.ServingQuantity,
+			})
+		}
+	}
 	body, err := json.Marshal(w)
 	if err != nil {
 		return err
@@ -342,7 +613,8 @@ func (c *Client) AddConsumedItem(req models.AddConsumedRequest) error {
 	return err
 }
 
-func newUUID() string {
+// NewUUID returns a random version 4 UUID, the format of consumed item IDs.
+func NewUUID() string {
 	b := make([]byte, 16)
 	io.ReadFull(rand.Reader, b)
 	b[6] = (b[6] & 0x0f) | 0x40 // version 4
@@ -353,7 +625,12 @@ func newUUID() string {
 // DeleteConsumedItem removes a consumed item by its consumed-item ID.
 // The API expects a DELETE to /user/consumed-items with the ID as a JSON array body.
 func (c *Client) DeleteConsumedItem(consumedID string) error {
-	body, err := json.Marshal([]string{consumedID})
+	return c.DeleteConsumedItems([]string{consumedID})
+}
+
+// DeleteConsumedItems removes several consumed items in one request.
+func (c *Client) DeleteConsumedItems(consumedIDs []string) error {
+	body, err := json.Marshal(consumedIDs)
 	if err != nil {
 		return err
 	}
@@ -361,47 +638,28 @@ func (c *Client) DeleteConsumedItem(consumedID string) error {
 	return err
 }
 
+// Raw sends a request with any method and optional JSON body and returns the
+// response body regardless of status code. Like the typed methods it refreshes
+// the access token on 401. Useful for exploring unknown endpoints.
+func (c *Client) Raw(method, path, body string) (string, int, error) {
+	var b []byte
+	if body != "" {
+		b = []byte(body)
+	}
+	data, status, err := c.refreshingRequest(method, path, b)
+	return string(data), status, err
+}
+
 // GetRaw fetches a raw API path and returns the response body as a string.
 // Useful for debugging unknown endpoints.
 func (c *Client) GetRaw(path string) (string, int, error) {
-	req, err := http.NewRequest("GET", base+path, nil)
-	if err != nil {
-		return "", 0, err
-	}
-	if c.token != "" {
-		req.Header.Set("Authorization", "Bearer "+c.token)
-	}
-	resp, err := c.http.Do(req)
-	if err != nil {
-		return "", 0, err
-	}
-	defer resp.Body.Close()
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", resp.StatusCode, err
-	}
-	return string(data), resp.StatusCode, nil
+	data, status, err := c.rawRequest("GET", path, nil)
+	return string(data), status, err
 }
 
 // PostRaw sends a raw JSON POST and returns the response body regardless of status code.
 // Useful for debugging API requests.
 func (c *Client) PostRaw(path, body string) (string, int, error) {
-	req, err := http.NewRequest("POST", base+path, bytes.NewBufferString(body))
-	if err != nil {
-		return "", 0, err
-	}
-	if c.token != "" {
-		req.Header.Set("Authorization", "Bearer "+c.token)
-	}
-	req.Header.Set("Content-Type", "application/json")
-	resp, err := c.http.Do(req)
-	if err != nil {
-		return "", 0, err
-	}
-	defer resp.Body.Close()
-	data, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", resp.StatusCode, err
-	}
-	return string(data), resp.StatusCode, nil
+	data, status, err := c.rawRequest("POST", path, []byte(body))
+	return string(data), status, err
 }
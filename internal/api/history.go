@@ -0,0 +1,64 @@
+package api
+
+import (
+	"sync"
+	"time"
+)
+
+// Call is one HTTP round trip as seen by the client. Bodies are redacted.
+type Call struct {
+	Seq          uint64 // numbers the calls of a History from 1, oldest first
+	Time         time.Time
+	Method       string
+	Path         string
+	Status       int // 0 when the request failed before a response arrived
+	Latency      time.Duration
+	RequestBody  string
+	ResponseBody string
+	Err          string
+}
+
+// History keeps the last N calls made by one or more clients, for the debug
+// page. It is safe for concurrent use.
+type History struct {
+	mu    sync.Mutex
+	calls []Call // ring buffer
+	next  int
+	full  bool
+	seq   uint64
+}
+
+// NewHistory returns a History holding up to size calls.
+func NewHistory(size int) *History {
+	if size < 1 {
+		size = 1
+	}
+	return &History{calls: make([]Call, size)}
+}
+
+func (h *History) add(c Call) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.seq++
+	c.Seq = h.seq
+	h.calls[h.next] = c
+	h.next = (h.next + 1) % len(h.calls)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// Calls returns the recorded calls, newest first.
+func (h *History) Calls() []Call {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	n := h.next
+	if h.full {
+		n = len(h.calls)
+	}
+	out := make([]Call, 0, n)
+	for i := 1; i <= n; i++ {
+		out = append(out, h.calls[(h.next-i+len(h.calls))%len(h.calls)])
+	}
+	return out
+}
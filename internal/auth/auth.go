@@ -70,6 +70,39 @@ func ConfigFilePath() string {
 	return path
 }
 
+// LoadJSON reads name (a file next to config.json) into v. A missing file
+// leaves v untouched and is not an error.
+func LoadJSON(name string, v any) error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(filepath.Join(filepath.Dir(path), name))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// SaveJSON writes v as indented JSON to name (a file next to config.json).
+func SaveJSON(name string, v any) error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(filepath.Dir(path), name), data, 0600)
+}
+
 func ClearToken() error {
 	path, err := configPath()
 	if err != nil {
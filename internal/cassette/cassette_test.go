@@ -0,0 +1,149 @@
+package cassette_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/koriwi/yazio-cli/internal/api"
+	"github.com/koriwi/yazio-cli/internal/cassette"
+)
+
+// upstream answers like the API would, echoing a few secrets back.
+func upstream(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/v15/oauth/token":
+			w.Header().Set("Set-Cookie", "session=abc")
+			io.WriteString(w, `{"access_token":"live-access","refresh_token":"live-refresh","expires_in":172800}`)
+		case "/v15/user/goals":
+			io.WriteString(w, `{"energy.energy":2000,"nutrient.protein":150,"nutrient.carb":250,"nutrient.fat":65}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	srv := upstream(t)
+	rec := cassette.NewRecorder(nil)
+	client := &http.Client{Transport: rec}
+
+	req, _ := http.NewRequest("POST", srv.URL+"/v15/oauth/token",
+		strings.NewReader(`{"username":"me@example.com","password":"hunter2","client_secret":"s"}`))
+	req.Header.Set("Authorization", "Bearer old")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	// The caller still sees the real response
+	if !strings.Contains(string(body), "live-access") {
+		t.Fatalf("recorder changed the response: %s", body)
+	}
+	resp, err = client.Get(srv.URL + "/v15/user/goals?date=2024-01-15")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	path := filepath.Join(t.TempDir(), "sub", "session.json")
+	if err := rec.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	c, err := cassette.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Interactions) != 2 {
+		t.Fatalf("recorded %d interactions, want 2", len(c.Interactions))
+	}
+
+	login := c.Interactions[0]
+	if login.Request.Method != "POST" || login.Request.URL != "/v15/oauth/token" {
+		t.Errorf("request = %s %s", login.Request.Method, login.Request.URL)
+	}
+	for _, secret := range []string{"me@example.com", "hunter2", "live-access", "live-refresh", "Bearer old", "session=abc"} {
+		for _, s := range []string{login.Request.Body, login.Response.Body, strings.Join(login.Request.Header["Authorization"], ""),
+			strings.Join(login.Response.Header["Set-Cookie"], "")} {
+			if strings.Contains(s, secret) {
+				t.Errorf("cassette contains %q: %s", secret, s)
+			}
+		}
+	}
+	if got := c.Interactions[1].Request.URL; got != "/v15/user/goals?date=2024-01-15" {
+		t.Errorf("URL = %q, want path and query only", got)
+	}
+
+	// Replay without the server
+	srv.Close()
+	rp := cassette.NewReplayer(c)
+	client = &http.Client{Transport: rp}
+	resp, err = client.Get("http://replay.invalid/v15/user/goals?date=2024-01-15")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != 200 || !strings.Contains(string(body), `"energy.energy":2000`) {
+		t.Errorf("replayed %d %s", resp.StatusCode, body)
+	}
+	if rp.Remaining() != 1 {
+		t.Errorf("Remaining() = %d, want 1", rp.Remaining())
+	}
+	if _, err := client.Get("http://replay.invalid/v15/user/goals?date=2024-01-15"); err == nil {
+		t.Error("an interaction was replayed twice")
+	}
+}
+
+func TestReplayerOrder(t *testing.T) {
+	c := &cassette.Cassette{Interactions: []cassette.Interaction{
+		{Request: cassette.Request{Method: "GET", URL: "/v15/user"}, Response: cassette.Response{Status: 401}},
+		{Request: cassette.Request{Method: "GET", URL: "/v15/user"}, Response: cassette.Response{Status: 200, Body: `{}`}},
+	}}
+	client := &http.Client{Transport: cassette.NewReplayer(c)}
+	for _, want := range []int{401, 200} {
+		resp, err := client.Get("http://replay.invalid/v15/user")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("status = %d, want %d", resp.StatusCode, want)
+		}
+	}
+	if _, err := client.Post("http://replay.invalid/v15/user", "application/json", nil); err == nil {
+		t.Error("POST matched a recorded GET")
+	}
+}
+
+func TestReplayClient(t *testing.T) {
+	c := &cassette.Cassette{Interactions: []cassette.Interaction{{
+		Request: cassette.Request{Method: "GET", URL: "/v15/user/goals?date=2024-01-15"},
+		Response: cassette.Response{
+			Status: 200,
+			Header: http.Header{"Content-Type": {"application/json"}},
+			Body:   `{"energy.energy":2100,"nutrient.protein":140,"nutrient.carb":230,"nutrient.fat":70}`,
+		},
+	}}}
+	client := api.New("token")
+	client.SetBaseURL("http://replay.invalid")
+	client.SetTransport(cassette.NewReplayer(c))
+
+	goals, err := client.GetGoals(time.Date(2024, 1, 15, 0, 0, 0, 0, time.Local))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if goals.EnergyKcal != 2100 || goals.Protein != 140 || goals.Carb != 230 || goals.Fat != 70 {
+		t.Errorf("goals = %+v", goals)
+	}
+}
@@ -0,0 +1,64 @@
+package diary
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxRangeDays is the longest range ParseDateRange accepts, so a typo can't
+// log something on thousands of days.
+const MaxRangeDays = 366
+
+// ParseDate parses a day: YYYY-MM-DD, today, yesterday, tomorrow or a number
+// of days relative to today such as -2 or +1. An empty value means today.
+func ParseDate(s string) (time.Time, error) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	switch strings.ToLower(s) {
+	case "", "today":
+		return today, nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	}
+	if s[0] == '-' || s[0] == '+' {
+		if n, err := strconv.Atoi(strings.TrimSuffix(s, "d")); err == nil {
+			return today.AddDate(0, 0, n), nil
+		}
+	}
+	t, err := time.ParseInLocation(time.DateOnly, s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, want YYYY-MM-DD, today, yesterday or a relative day like -2", s)
+	}
+	return t, nil
+}
+
+// ParseDateRange parses a day like ParseDate, or a range of them such as
+// 2024-01-15..2024-01-21 or +1..+7, into every day it covers.
+func ParseDateRange(s string) ([]time.Time, error) {
+	fromStr, toStr, isRange := strings.Cut(strings.TrimSpace(s), "..")
+	from, err := ParseDate(strings.TrimSpace(fromStr))
+	if err != nil {
+		return nil, err
+	}
+	to := from
+	if isRange {
+		if to, err = ParseDate(strings.TrimSpace(toStr)); err != nil {
+			return nil, err
+		}
+	}
+	if from.After(to) {
+		return nil, fmt.Errorf("%s is after %s", from.Format(time.DateOnly), to.Format(time.DateOnly))
+	}
+	var days []time.Time
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		if len(days) == MaxRangeDays {
+			return nil, fmt.Errorf("range is longer than %d days", MaxRangeDays)
+		}
+		days = append(days, d)
+	}
+	return days, nil
+}
@@ -0,0 +1,99 @@
+package diary
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	tests := []struct {
+		in   string
+		want time.Time
+	}{
+		{"", today},
+		{"today", today},
+		{"Today", today},
+		{"yesterday", today.AddDate(0, 0, -1)},
+		{"tomorrow", today.AddDate(0, 0, 1)},
+		{"-2", today.AddDate(0, 0, -2)},
+		{"+1", today.AddDate(0, 0, 1)},
+		{"+7d", today.AddDate(0, 0, 7)},
+		{"-30d", today.AddDate(0, 0, -30)},
+		{"2024-01-15", time.Date(2024, 1, 15, 0, 0, 0, 0, time.Local)},
+		{"2024-02-29", time.Date(2024, 2, 29, 0, 0, 0, 0, time.Local)},
+	}
+	for _, tt := range tests {
+		got, err := ParseDate(tt.in)
+		if err != nil {
+			t.Errorf("ParseDate(%q): %v", tt.in, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseDate(%q) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"2023-02-29", "15.01.2024", "2024-1-5", "-", "+x", "next week", "1"} {
+		if got, err := ParseDate(in); err == nil {
+			t.Errorf("ParseDate(%q) = %s, want an error", in, got)
+		}
+	}
+}
+
+func TestParseDateRange(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.Local) }
+	tests := []struct {
+		in   string
+		want []time.Time
+	}{
+		{"2024-01-15", []time.Time{day(15)}},
+		{" 2024-01-15 ", []time.Time{day(15)}},
+		{"2024-01-15..2024-01-17", []time.Time{day(15), day(16), day(17)}},
+		{"2024-01-15 .. 2024-01-16", []time.Time{day(15), day(16)}},
+		{"2024-01-31..2024-01-31", []time.Time{day(31)}},
+	}
+	for _, tt := range tests {
+		got, err := ParseDateRange(tt.in)
+		if err != nil {
+			t.Errorf("ParseDateRange(%q): %v", tt.in, err)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("ParseDateRange(%q) = %v, want %v", tt.in, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if !got[i].Equal(tt.want[i]) {
+				t.Errorf("ParseDateRange(%q)[%d] = %s, want %s", tt.in, i, got[i], tt.want[i])
+			}
+		}
+	}
+
+	rel, err := ParseDateRange("+1..+7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rel) != 7 {
+		t.Errorf("+1..+7 covers %d days, want 7", len(rel))
+	}
+
+	// Across a month and a DST change, every day shows up once
+	days, err := ParseDateRange("2024-03-25..2024-04-05")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(days) != 12 || days[11].Format(time.DateOnly) != "2024-04-05" {
+		t.Errorf("got %d days ending %s", len(days), days[len(days)-1].Format(time.DateOnly))
+	}
+
+	if _, err := ParseDateRange("2024-01-01..2024-12-31"); err != nil {
+		t.Errorf("a leap year (%d days) was rejected: %v", MaxRangeDays, err)
+	}
+	for _, in := range []string{"2024-01-17..2024-01-15", "2023-01-01..2024-12-31", "x..y", "2024-01-15..y"} {
+		if got, err := ParseDateRange(in); err == nil {
+			t.Errorf("ParseDateRange(%q) = %d days, want an error", in, len(got))
+		}
+	}
+}
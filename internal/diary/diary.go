@@ -0,0 +1,367 @@
+// Package diary resolves a day's consumed items into entries with names and
+// nutrients. It is shared by the TUI and the command line.
+package diary
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/koriwi/yazio-cli/internal/api"
+	"github.com/koriwi/yazio-cli/internal/models"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/singleflight"
+)
+
+// MaxConcurrentFetches bounds the number of product/recipe lookups in flight
+// while resolving a day's entries.
+const MaxConcurrentFetches = 6
+
+// fetchGroup de-duplicates concurrent lookups of the same product or recipe,
+// so two entries with the same ProductID only cost one request.
+var fetchGroup singleflight.Group
+
+// Day is everything shown for one diary day.
+type Day struct {
+	Date    time.Time
+	Entries []models.DiaryEntry
+	Goals   *models.GoalsResponse
+	Totals  *models.DailyNutrient
+}
+
+// Load fetches the consumed items, goals and totals of date in parallel and
+// resolves the entries. cache may be nil.
+func Load(client *api.Client, date time.Time, cache *sync.Map) (*Day, error) {
+	if cache == nil {
+		cache = &sync.Map{}
+	}
+	day := &Day{Date: date}
+	var consumed *models.ConsumedItemsResponse
+	var g errgroup.Group
+	g.Go(func() (err error) {
+		consumed, err = client.GetConsumedItems(date)
+		return err
+	})
+	g.Go(func() (err error) {
+		day.Goals, err = client.GetGoals(date)
+		return err
+	})
+	g.Go(func() (err error) {
+		day.Totals, err = client.GetDailyNutrients(date)
+		return err
+	})
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	entries, err := ResolveEntries(consumed, client, cache)
+	if err != nil {
+		return nil, err
+	}
+	day.Entries = entries
+	return day, nil
+}
+
+// LoggedDays returns the days from start to end that have anything logged.
+// Days with only zero-calorie items don't show up in the daily totals, so if
+// the range request fails every day is returned instead.
+func LoggedDays(client *api.Client, start, end time.Time) ([]time.Time, error) {
+	var all []time.Time
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		all = append(all, d)
+	}
+	totals, err := client.GetDailyNutrientsRange(start, end)
+	if err != nil {
+		if errors.Is(err, api.ErrSessionExpired) {
+			return nil, err
+		}
+		return all, nil
+	}
+	logged := map[string]bool{}
+	for _, t := range totals {
+		if t.Energy > 0 || t.Carb > 0 || t.Protein > 0 || t.Fat > 0 {
+			logged[t.Date] = true
+		}
+	}
+	var days []time.Time
+	for _, d := range all {
+		if logged[d.Format(time.DateOnly)] {
+			days = append(days, d)
+		}
+	}
+	return days, nil
+}
+
+// Sum adds up the nutrients of entries. Date and EnergyGoal are left empty.
+func Sum(entries []models.DiaryEntry) models.DailyNutrient {
+	var t models.DailyNutrient
+	for _, e := range entries {
+		t.Energy += e.Kcal
+		t.Protein += e.Protein
+		t.Carb += e.Carbs
+		t.Fat += e.Fat
+	}
+	return t
+}
+
+// ResolveEntries looks up the product or recipe of every consumed item with a
+// bounded worker pool, sorted by meal time. Items whose lookup fails are still
+// returned, with Err set. The returned error is only non-nil when the session
+// has expired.
+func ResolveEntries(consumed *models.ConsumedItemsResponse, client *api.Client, cache *sync.Map) ([]models.DiaryEntry, error) {
+	if consumed == nil {
+		return nil, nil
+	}
+
+	type job func() models.DiaryEntry
+	var jobs []job
+	var expired atomic.Bool
+	check := func(err error) {
+		if errors.Is(err, api.ErrSessionExpired) {
+			expired.Store(true)
+		}
+	}
+
+	for _, cp := range consumed.Products {
+		jobs = append(jobs, func() models.DiaryEntry {
+			product, err := FetchProduct(cp.ProductID, client, cache)
+			check(err)
+			return buildEntry(cp.ID, cp.ProductID, cp.Daytime, cp, product, err)
+		})
+	}
+	for _, cr := range consumed.RecipePortions {
+		jobs = append(jobs, func() models.DiaryEntry {
+			product, err := FetchRecipe(cr.RecipeID, client, cache)
+			check(err)
+			return buildRecipeEntry(cr.ID, cr.RecipeID, cr.Daytime, cr.PortionCount, product, err)
+		})
+	}
+	for _, cp := range consumed.SimpleProducts {
+		jobs = append(jobs, func() models.DiaryEntry {
+			product, err := FetchProduct(cp.ProductID, client, cache)
+			check(err)
+			return buildEntry(cp.ID, cp.ProductID, cp.Daytime, cp, product, err)
+		})
+	}
+
+	entries := make([]models.DiaryEntry, len(jobs))
+	var g errgroup.Group
+	g.SetLimit(MaxConcurrentFetches)
+	for i, j := range jobs {
+		g.Go(func() error {
+			entries[i] = j()
+			return nil
+		})
+	}
+	g.Wait()
+
+	if expired.Load() {
+		return nil, api.ErrSessionExpired
+	}
+
+	SortByMeal(entries)
+	return entries, nil
+}
+
+// SortByMeal orders entries by meal time, keeping the order within a meal.
+func SortByMeal(entries []models.DiaryEntry) {
+	order := map[string]int{"breakfast": 0, "lunch": 1, "dinner": 2, "snack": 3}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return order[entries[i].MealTime] < order[entries[j].MealTime]
+	})
+}
+
+// FetchProduct returns a product from cache, loading it if needed.
+func FetchProduct(productID string, client *api.Client, cache *sync.Map) (*models.ProductResponse, error) {
+	return fetchCached(productID, cache, func() (*models.ProductResponse, error) {
+		return client.GetProduct(productID)
+	})
+}
+
+// FetchRecipe returns a recipe from cache, loading it if needed.
+func FetchRecipe(recipeID string, client *api.Client, cache *sync.Map) (*models.ProductResponse, error) {
+	return fetchCached("recipe:"+recipeID, cache, func() (*models.ProductResponse, error) {
+		return client.GetRecipe(recipeID)
+	})
+}
+
+// fetchCached returns the cached value for key, or calls fetch once for all
+// concurrent callers asking for the same key. Failures are not cached.
+func fetchCached(key string, cache *sync.Map, fetch func() (*models.ProductResponse, error)) (*models.ProductResponse, error) {
+	if v, ok := cache.Load(key); ok {
+		p, _ := v.(*models.ProductResponse)
+		return p, nil
+	}
+	do := func() (any, error) {
+		p, err := fetch()
+		if err != nil {
+			return nil, err
+		}
+		cache.Store(key, p)
+		return p, nil
+	}
+	v, err, _ := fetchGroup.Do(key, do)
+	if errors.Is(err, context.Canceled) {
+		// We joined a lookup started by a load that has since been
+		// superseded; run our own (it fails fast if we were cancelled too).
+		v, err, _ = fetchGroup.Do(key, do)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return v.(*models.ProductResponse), nil
+}
+
+// NewEntry returns the entry that logging req for p creates, with its
+// nutrients, e.g. to show it before or after adding it.
+func NewEntry(p *models.ProductResponse, req models.AddConsumedRequest) models.DiaryEntry {
+	if req.Type == "recipe_portion" {
+		return buildRecipeEntry(req.ID, req.ProductID, req.Daytime, req.ServingQuantity, p, nil)
+	}
+	cp := models.ConsumedProduct{Amount: req.Amount, Serving: req.Serving, ServingQuantity: req.ServingQuantity}
+	return buildEntry(req.ID, req.ProductID, req.Daytime, cp, p, nil)
+}
+
+// CopyRequests returns requests that log entries again on each of dates, with
+// new consumed item IDs, ordered by date and then like entries. mealTime
+// moves the copies to that meal; "" keeps each entry's own.
+func CopyRequests(entries []models.DiaryEntry, dates []time.Time, mealTime string) []models.AddConsumedRequest {
+	var reqs []models.AddConsumedRequest
+	for _, d := range dates {
+		for _, e := range entries {
+			req := models.AddConsumedRequest{
+				ID:              api.NewUUID(),
+				ProductID:       e.ProductID,
+				Date:            d.Format(time.DateOnly),
+				Daytime:         e.MealTime,
+				Amount:          e.Amount,
+				Serving:         e.Serving,
+				ServingQuantity: e.ServingQuantity,
+				Type:            "product",
+			}
+			if e.Recipe {
+				req.Type = "recipe_portion"
+			}
+			if mealTime != "" {
+				req.Daytime = mealTime
+			}
+			reqs = append(reqs, req)
+		}
+	}
+	return reqs
+}
+
+// AddAll logs reqs with one request per day, so a failure leaves whole days
+// added or not. It returns how many were added before an error.
+func AddAll(client *api.Client, reqs []models.AddConsumedRequest) (int, error) {
+	added := 0
+	for start := 0; start < len(reqs); {
+		end := start + 1
+		for end < len(reqs) && reqs[end].Date == reqs[start].Date {
+			end++
+		}
+		if err := client.AddConsumedItems(reqs[start:end]); err != nil {
+			return added, err
+		}
+		added += end - start
+		start = end
+	}
+	return added, nil
+}
+
+// ScaleRequests returns requests that log entries of date again with their
+// amounts multiplied by factor, with new consumed item IDs.
+func ScaleRequests(entries []models.DiaryEntry, date time.Time, factor float64) []models.AddConsumedRequest {
+	reqs := CopyRequests(entries, []time.Time{date}, "")
+	for i := range reqs {
+		reqs[i].Amount = math.Round(reqs[i].Amount*factor*10) / 10
+		reqs[i].ServingQuantity = math.Round(reqs[i].ServingQuantity*factor*100) / 100
+	}
+	return reqs
+}
+
+// Replace swaps entries for reqs, e.g. from CopyRequests to move entries to
+// another meal or from ScaleRequests. The API can't change an entry, so reqs
+// are logged first and only then are the entries deleted; if that fails, reqs
+// are removed again, so nothing is lost or doubled. Either side may be empty.
+func Replace(client *api.Client, entries []models.DiaryEntry, reqs []models.AddConsumedRequest) error {
+	if len(reqs) > 0 {
+		if err := client.AddConsumedItems(reqs); err != nil {
+			return err
+		}
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	ids := make([]string, len(entries))
+	for i, e := range entries {
+		ids[i] = e.ConsumedID
+	}
+	if err := client.DeleteConsumedItems(ids); err != nil {
+		if len(reqs) > 0 {
+			added := make([]string, len(reqs))
+			for i, r := range reqs {
+				added[i] = r.ID
+			}
+			client.DeleteConsumedItems(added)
+		}
+		return err
+	}
+	return nil
+}
+
+func buildEntry(consumedID, productID, mealTime string, cp models.ConsumedProduct, p *models.ProductResponse, err error) models.DiaryEntry {
+	e := models.DiaryEntry{
+		ConsumedID:      consumedID,
+		ProductID:       productID,
+		MealTime:        mealTime,
+		Amount:          cp.Amount,
+		Serving:         cp.Serving,
+		ServingQuantity: cp.ServingQuantity,
+	}
+	amount := cp.Amount
+	if p == nil {
+		e.Err = resolveError(err)
+		return e
+	}
+	e.Name = p.Name
+	// amount is total grams; nutrients are per gram → multiply directly
+	e.Kcal = math.Round(p.Nutrients.EnergyKcal*amount*10) / 10
+	e.Protein = math.Round(p.Nutrients.Protein*amount*10) / 10
+	e.Carbs = math.Round(p.Nutrients.Carb*amount*10) / 10
+	e.Fat = math.Round(p.Nutrients.Fat*amount*10) / 10
+	return e
+}
+
+func buildRecipeEntry(consumedID, recipeID, mealTime string, portions float64, p *models.ProductResponse, err error) models.DiaryEntry {
+	e := models.DiaryEntry{
+		ConsumedID:      consumedID,
+		ProductID:       recipeID,
+		MealTime:        mealTime,
+		Amount:          portions,
+		Serving:         "portion",
+		ServingQuantity: portions,
+		Recipe:          true,
+	}
+	if p == nil {
+		e.Err = resolveError(err)
+		return e
+	}
+	e.Name = p.Name
+	e.Kcal = math.Round(p.Nutrients.EnergyKcal*portions*10) / 10
+	e.Protein = math.Round(p.Nutrients.Protein*portions*10) / 10
+	e.Carbs = math.Round(p.Nutrients.Carb*portions*10) / 10
+	e.Fat = math.Round(p.Nutrients.Fat*portions*10) / 10
+	return e
+}
+
+func resolveError(err error) string {
+	if err == nil {
+		return "not found"
+	}
+	return err.Error()
+}
@@ -0,0 +1,165 @@
+package diary
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/koriwi/yazio-cli/internal/api"
+	"github.com/koriwi/yazio-cli/internal/fakeserver"
+	"github.com/koriwi/yazio-cli/internal/models"
+)
+
+func TestScaleRequests(t *testing.T) {
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.Local)
+	entries := []models.DiaryEntry{
+		{ConsumedID: "a", ProductID: "oats", MealTime: "breakfast", Amount: 80, Serving: "gram", ServingQuantity: 80},
+		{ConsumedID: "b", ProductID: "banana", MealTime: "snack", Amount: 118, Serving: "piece", ServingQuantity: 1},
+		{ConsumedID: "c", ProductID: "chili", MealTime: "dinner", Amount: 1, ServingQuantity: 1, Recipe: true},
+		{ConsumedID: "d", ProductID: "milk", MealTime: "breakfast", Amount: 33, Serving: "cup", ServingQuantity: 0.333},
+	}
+	tests := []struct {
+		factor float64
+		amount []float64
+		qty    []float64
+	}{
+		{1.5, []float64{120, 177, 1.5, 49.5}, []float64{120, 1.5, 1.5, 0.5}},
+		{0.5, []float64{40, 59, 0.5, 16.5}, []float64{40, 0.5, 0.5, 0.17}},
+		{1 / 3.0, []float64{26.7, 39.3, 0.3, 11}, []float64{26.67, 0.33, 0.33, 0.11}},
+		{2, []float64{160, 236, 2, 66}, []float64{160, 2, 2, 0.67}},
+	}
+	for _, tt := range tests {
+		reqs := ScaleRequests(entries, date, tt.factor)
+		if len(reqs) != len(entries) {
+			t.Fatalf("factor %g: %d requests, want %d", tt.factor, len(reqs), len(entries))
+		}
+		for i, r := range reqs {
+			e := entries[i]
+			if r.Amount != tt.amount[i] || r.ServingQuantity != tt.qty[i] {
+				t.Errorf("factor %g, %s: amount %g × %g, want %g × %g", tt.factor, e.ProductID, r.Amount, r.ServingQuantity, tt.amount[i], tt.qty[i])
+			}
+			if r.ID == "" || r.ID == e.ConsumedID {
+				t.Errorf("%s: ID %q, want a new one", e.ProductID, r.ID)
+			}
+			if r.Date != "2024-01-15" || r.Daytime != e.MealTime || r.Serving != e.Serving || r.ProductID != e.ProductID {
+				t.Errorf("%s: request %+v doesn't match the entry", e.ProductID, r)
+			}
+		}
+		if reqs[2].Type != "recipe_portion" || reqs[0].Type != "product" {
+			t.Errorf("types = %s, %s", reqs[0].Type, reqs[2].Type)
+		}
+	}
+}
+
+// failingServer serves the fake API but answers the next *fail consumed-items
+// requests with method with a 500.
+func failingServer(t *testing.T, method string, fail *int) (*api.Client, func() []string) {
+	t.Helper()
+	fake := fakeserver.New()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if *fail > 0 && r.Method == method && r.URL.Path == "/v15/user/consumed-items" {
+			*fail--
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		fake.Handler().ServeHTTP(w, r)
+	}))
+	t.Cleanup(ts.Close)
+	access, _ := fake.IssueTokens(fakeserver.DefaultEmail)
+	client := api.New(access)
+	client.SetBaseURL(ts.URL)
+
+	ids := func() []string {
+		products, recipes := fake.Consumed(fakeserver.DefaultEmail, "2024-01-15")
+		var out []string
+		for _, p := range products {
+			out = append(out, p.ID+"/"+p.Daytime)
+		}
+		for _, r := range recipes {
+			out = append(out, r.ID+"/"+r.Daytime)
+		}
+		sort.Strings(out)
+		return out
+	}
+	return client, ids
+}
+
+func TestReplace(t *testing.T) {
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.Local)
+	seed := []models.AddConsumedRequest{
+		{ID: "oats", ProductID: fakeserver.ProductOats, Date: "2024-01-15", Daytime: "breakfast", Amount: 80, Serving: "gram", ServingQuantity: 80, Type: "product"},
+		{ID: "chili", ProductID: fakeserver.RecipeChili, Date: "2024-01-15", Daytime: "dinner", Amount: 1, ServingQuantity: 1, Type: "recipe_portion"},
+	}
+	entries := []models.DiaryEntry{
+		{ConsumedID: "oats", ProductID: fakeserver.ProductOats, MealTime: "breakfast", Amount: 80, Serving: "gram", ServingQuantity: 80},
+		{ConsumedID: "chili", ProductID: fakeserver.RecipeChili, MealTime: "dinner", Amount: 1, ServingQuantity: 1, Recipe: true},
+	}
+	before := []string{"chili/dinner", "oats/breakfast"}
+
+	tests := []struct {
+		name    string
+		method  string // requests that fail
+		wantErr bool
+		moved   bool
+	}{
+		{name: "success", moved: true},
+		{name: "add fails", method: "POST", wantErr: true},
+		{name: "delete fails and the copies are removed", method: "DELETE", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fail := 0
+			client, ids := failingServer(t, tt.method, &fail)
+			if err := client.AddConsumedItems(seed); err != nil {
+				t.Fatal(err)
+			}
+			reqs := CopyRequests(entries, []time.Time{date}, "lunch")
+			fail = 1
+			err := Replace(client, entries, reqs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			got := ids()
+			want := before
+			if tt.moved {
+				want = []string{reqs[0].ID + "/lunch", reqs[1].ID + "/lunch"}
+				sort.Strings(want)
+			}
+			if len(got) != len(want) {
+				t.Fatalf("diary = %v, want %v", got, want)
+			}
+			for i := range got {
+				if got[i] != want[i] {
+					t.Fatalf("diary = %v, want %v", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestReplaceOneSided(t *testing.T) {
+	fail := 0
+	client, ids := failingServer(t, "", &fail)
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.Local)
+	entry := models.DiaryEntry{ConsumedID: "x", ProductID: fakeserver.ProductBanana, MealTime: "snack", Amount: 118, Serving: "piece", ServingQuantity: 1}
+
+	// Only adding, as undoing a delete does
+	reqs := CopyRequests([]models.DiaryEntry{entry}, []time.Time{date}, "")
+	if err := Replace(client, nil, reqs); err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(); len(got) != 1 || got[0] != reqs[0].ID+"/snack" {
+		t.Fatalf("after adding: %v", got)
+	}
+
+	// Only deleting, as undoing an add does
+	entry.ConsumedID = reqs[0].ID
+	if err := Replace(client, []models.DiaryEntry{entry}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(); len(got) != 0 {
+		t.Fatalf("after deleting: %v", got)
+	}
+}
@@ -0,0 +1,124 @@
+package fakeserver
+
+// Seed IDs are fixed so tests and manual sessions can refer to them.
+const (
+	ProductBanana       = "7a2e0f4c-1b9d-4c7e-8f3a-000000000001"
+	ProductOats         = "7a2e0f4c-1b9d-4c7e-8f3a-000000000002"
+	ProductGreekYogurt  = "7a2e0f4c-1b9d-4c7e-8f3a-000000000003"
+	ProductChicken      = "7a2e0f4c-1b9d-4c7e-8f3a-000000000004"
+	ProductMilk         = "7a2e0f4c-1b9d-4c7e-8f3a-000000000005"
+	ProductBread        = "7a2e0f4c-1b9d-4c7e-8f3a-000000000006"
+	ProductEgg          = "7a2e0f4c-1b9d-4c7e-8f3a-000000000007"
+	ProductApple        = "7a2e0f4c-1b9d-4c7e-8f3a-000000000008"
+	ProductRice         = "7a2e0f4c-1b9d-4c7e-8f3a-000000000009"
+	ProductPeanutButter = "7a2e0f4c-1b9d-4c7e-8f3a-00000000000a"
+	ProductCookie       = "7a2e0f4c-1b9d-4c7e-8f3a-00000000000b"
+	ProductSkyr         = "7a2e0f4c-1b9d-4c7e-8f3a-00000000000c"
+
+	RecipePorridge = "3c5d9b1e-6f2a-4d8b-9e7c-000000000001"
+	RecipeChili    = "3c5d9b1e-6f2a-4d8b-9e7c-000000000002"
+)
+
+func producer(name string) *string { return &name }
+
+// nutrients builds a per-gram nutrient map from per-100g values.
+func nutrients(kcal, carb, protein, fat, sugar, saturated, salt float64) map[string]float64 {
+	return map[string]float64{
+		"energy.energy":      kcal / 100,
+		"nutrient.carb":      carb / 100,
+		"nutrient.protein":   protein / 100,
+		"nutrient.fat":       fat / 100,
+		"nutrient.sugar":     sugar / 100,
+		"nutrient.saturated": saturated / 100,
+		"nutrient.salt":      salt / 100,
+	}
+}
+
+var seedProducts = []Product{
+	{
+		ID: ProductBanana, Name: "Banana", IsVerified: true, Category: "fruits", BaseUnit: "g",
+		Nutrients: nutrients(89, 20, 1.1, 0.3, 17, 0.1, 0),
+		Servings:  []Serving{{Serving: "piece", Amount: 120}, {Serving: "gram", Amount: 1}},
+		Language:  "en",
+	},
+	{
+		ID: ProductOats, Name: "Rolled Oats", Producer: producer("Kölln"), IsVerified: true, Category: "grains", BaseUnit: "g",
+		Nutrients: nutrients(372, 58.7, 13.5, 7, 0.7, 1.3, 0.01),
+		Servings:  []Serving{{Serving: "cup", Amount: 80}, {Serving: "gram", Amount: 1}},
+		Language:  "en", Countries: []string{"DE", "AT", "CH"},
+	},
+	{
+		ID: ProductGreekYogurt, Name: "Greek Yogurt 10%", Producer: producer("Fage"), IsVerified: true, Category: "dairy", BaseUnit: "g",
+		Nutrients: nutrients(133, 3.5, 5.7, 10, 3.5, 7, 0.1),
+		Servings:  []Serving{{Serving: "cup", Amount: 150}, {Serving: "gram", Amount: 1}},
+		Language:  "en",
+	},
+	{
+		ID: ProductChicken, Name: "Chicken Breast", IsVerified: true, Category: "meat", BaseUnit: "g",
+		Nutrients: nutrients(110, 0, 23.5, 1.2, 0, 0.3, 0.15),
+		Servings:  []Serving{{Serving: "gram", Amount: 1}},
+		Language:  "en",
+	},
+	{
+		ID: ProductMilk, Name: "Milk 1.5%", Producer: producer("Weihenstephan"), IsVerified: true, Category: "dairy", BaseUnit: "ml",
+		Nutrients: nutrients(47, 4.9, 3.4, 1.5, 4.9, 1, 0.1),
+		Servings:  []Serving{{Serving: "glass", Amount: 200}, {Serving: "gram", Amount: 1}},
+		Language:  "en", Countries: []string{"DE"},
+	},
+	{
+		ID: ProductBread, Name: "Whole Grain Bread", IsVerified: false, Category: "bakery", BaseUnit: "g",
+		Nutrients: nutrients(247, 41, 13, 3.4, 6, 0.7, 1.1),
+		Servings:  []Serving{{Serving: "slice", Amount: 45}, {Serving: "gram", Amount: 1}},
+		Language:  "en",
+	},
+	{
+		ID: ProductEgg, Name: "Egg", IsVerified: true, Category: "eggs", BaseUnit: "g",
+		Nutrients: nutrients(155, 1.1, 13, 11, 1.1, 3.3, 0.3),
+		Servings:  []Serving{{Serving: "piece", Amount: 60}, {Serving: "gram", Amount: 1}},
+		Language:  "en",
+	},
+	{
+		ID: ProductApple, Name: "Apple", IsVerified: true, Category: "fruits", BaseUnit: "g",
+		Nutrients: nutrients(52, 14, 0.3, 0.2, 10, 0, 0),
+		Servings:  []Serving{{Serving: "piece", Amount: 180}, {Serving: "gram", Amount: 1}},
+		Language:  "en",
+	},
+	{
+		ID: ProductRice, Name: "Basmati Rice, cooked", IsVerified: true, Category: "grains", BaseUnit: "g",
+		Nutrients: nutrients(121, 25, 3.5, 0.4, 0.1, 0.1, 0),
+		Servings:  []Serving{{Serving: "cup", Amount: 160}, {Serving: "gram", Amount: 1}},
+		Language:  "en",
+	},
+	{
+		ID: ProductPeanutButter, Name: "Peanut Butter Crunchy", Producer: producer("Barney's"), IsVerified: false, Category: "spreads", BaseUnit: "g",
+		Nutrients: nutrients(598, 12, 25, 50, 6, 10, 0.5),
+		Servings:  []Serving{{Serving: "tablespoon", Amount: 16}, {Serving: "gram", Amount: 1}},
+		Language:  "en", Countries: []string{"US", "DE"},
+	},
+	{
+		ID: ProductCookie, Name: "Chocolate Chip Cookie", Producer: producer("Bahlsen"), IsVerified: false, Category: "sweets", BaseUnit: "g",
+		Nutrients: nutrients(488, 64, 5.5, 23, 35, 12, 0.6),
+		Servings:  []Serving{{Serving: "cookie", Amount: 12}, {Serving: "package", Amount: 200}, {Serving: "gram", Amount: 1}},
+		Language:  "en",
+	},
+	{
+		ID: ProductSkyr, Name: "Skyr Natural", Producer: producer("Arla"), IsVerified: true, Category: "dairy", BaseUnit: "g",
+		Nutrients: nutrients(63, 4, 11, 0.2, 4, 0.1, 0.1),
+		Servings:  []Serving{{Serving: "cup", Amount: 150}, {Serving: "gram", Amount: 1}},
+		Language:  "en", Countries: []string{"DE", "IS"},
+	},
+}
+
+// Recipe nutrients are per portion.
+var seedRecipes = []Recipe{
+	{
+		ID: RecipePorridge, Name: "Banana Porridge", BaseUnit: "portion",
+		Nutrients: map[string]float64{"energy.energy": 420, "nutrient.carb": 68, "nutrient.protein": 15, "nutrient.fat": 9},
+		Servings:  []Serving{{Serving: "portion", Amount: 1}},
+	},
+	{
+		ID: RecipeChili, Name: "Chili con Carne", BaseUnit: "portion",
+		Nutrients: map[string]float64{"energy.energy": 610, "nutrient.carb": 45, "nutrient.protein": 42, "nutrient.fat": 27},
+		Servings:  []Serving{{Serving: "portion", Amount: 1}},
+	},
+}
@@ -0,0 +1,687 @@
+// Package fakeserver is an in-memory implementation of the YAZIO endpoints
+// described in yazio-api.yaml, for end-to-end tests of the TUI and CLI and for
+// poking at the app without a real account (`yazio-cli fake-server`).
+//
+// It implements /oauth/token with rotating refresh tokens, the user profile,
+// goals, consumed-items GET/POST/DELETE, nutrients-daily, products, product
+// search and recipes. All state lives in memory and is lost on Close.
+package fakeserver
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultEmail and DefaultPassword are the credentials of the seeded user.
+	DefaultEmail    = "demo@example.com"
+	DefaultPassword = "demo"
+
+	// AccessTokenLifetime is reported as expires_in, like the real API.
+	AccessTokenLifetime = 48 * time.Hour
+
+	prefix = "/v15"
+)
+
+// Product is a food in the fake database. Nutrients are per gram and use the
+// API's dotted keys ("energy.energy", "nutrient.protein", …).
+type Product struct {
+	ID         string             `json:"id"`
+	Name       string             `json:"name"`
+	Producer   *string            `json:"producer"`
+	IsVerified bool               `json:"is_verified"`
+	IsPrivate  bool               `json:"is_private"`
+	IsDeleted  bool               `json:"is_deleted"`
+	HasEAN     bool               `json:"has_ean"`
+	Category   string             `json:"category"`
+	BaseUnit   string             `json:"base_unit"`
+	Nutrients  map[string]float64 `json:"nutrients"`
+	Servings   []Serving          `json:"servings"`
+	EANs       []string           `json:"eans"`
+	Language   string             `json:"language"`
+	Countries  []string           `json:"countries"`
+	UpdatedAt  string             `json:"updated_at"`
+}
+
+type Serving struct {
+	Serving string  `json:"serving"`
+	Amount  float64 `json:"amount"`
+}
+
+// Recipe has the same shape as a product; nutrients are per portion.
+type Recipe = Product
+
+// ConsumedProduct is a product entry in a user's diary.
+type ConsumedProduct struct {
+	ID              string  `json:"id"`
+	ProductID       string  `json:"product_id"`
+	Date            string  `json:"date"`
+	Daytime         string  `json:"daytime"`
+	Amount          float64 `json:"amount"`
+	Serving         string  `json:"serving"`
+	ServingQuantity float64 `json:"serving_quantity"`
+	Type            string  `json:"type"`
+}
+
+// ConsumedRecipe is a recipe portion entry in a user's diary.
+type ConsumedRecipe struct {
+	ID           string  `json:"id"`
+	RecipeID     string  `json:"recipe_id"`
+	Date         string  `json:"date"`
+	Daytime      string  `json:"daytime"`
+	PortionCount float64 `json:"portion_count"`
+}
+
+// Profile is the user object returned by GET /user.
+type Profile struct {
+	UUID                string `json:"uuid"`
+	Email               string `json:"email"`
+	FirstName           string `json:"first_name"`
+	LastName            string `json:"last_name"`
+	Country             string `json:"country"`
+	Sex                 string `json:"sex"`
+	Language            string `json:"language"`
+	FoodDatabaseCountry string `json:"food_database_country"`
+}
+
+type user struct {
+	password string
+	profile  Profile
+	goals    map[string]float64
+	products []ConsumedProduct
+	recipes  []ConsumedRecipe
+}
+
+// Server is a fake YAZIO API. The zero value is not usable; create one with
+// New (unstarted handler) or Start (running httptest server).
+type Server struct {
+	mu       sync.Mutex
+	users    map[string]*user  // by email
+	access   map[string]string // access token → email
+	refresh  map[string]string // refresh token → email
+	products map[string]Product
+	recipes  map[string]Recipe
+
+	httpServer *httptest.Server
+}
+
+// New returns a fake server seeded with the demo user and a small food
+// database. Use Handler to serve it yourself.
+func New() *Server {
+	s := &Server{
+		users:    map[string]*user{},
+		access:   map[string]string{},
+		refresh:  map[string]string{},
+		products: map[string]Product{},
+		recipes:  map[string]Recipe{},
+	}
+	s.AddUser(DefaultEmail, DefaultPassword)
+	for _, p := range seedProducts {
+		s.AddProduct(p)
+	}
+	for _, r := range seedRecipes {
+		s.AddRecipe(r)
+	}
+	return s
+}
+
+// Start returns a seeded fake server listening on a random local port.
+func Start() *Server {
+	s := New()
+	s.httpServer = httptest.NewServer(s.Handler())
+	return s
+}
+
+// URL returns the base URL of a started server, suitable for
+// api.Client.SetBaseURL or the YAZIO_BASE_URL environment variable.
+func (s *Server) URL() string {
+	if s.httpServer == nil {
+		return ""
+	}
+	return s.httpServer.URL
+}
+
+// Close shuts down a started server.
+func (s *Server) Close() {
+	if s.httpServer != nil {
+		s.httpServer.Close()
+	}
+}
+
+// AddUser creates (or resets) an account with default goals and profile.
+func (s *Server) AddUser(email, password string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[email] = &user{
+		password: password,
+		profile: Profile{
+			UUID:                newID(),
+			Email:               email,
+			FirstName:           "Demo",
+			LastName:            "User",
+			Country:             "DE",
+			Sex:                 "female",
+			Language:            "en",
+			FoodDatabaseCountry: "DE",
+		},
+		goals: map[string]float64{
+			"energy.energy":    2000,
+			"nutrient.carb":    250,
+			"nutrient.protein": 150,
+			"nutrient.fat":     65,
+			"activity.step":    10000,
+			"bodyvalue.weight": 70,
+			"water":            2000,
+		},
+	}
+}
+
+// SetGoals overrides goal values (dotted keys) for a user.
+func (s *Server) SetGoals(email string, goals map[string]float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if u := s.users[email]; u != nil {
+		for k, v := range goals {
+			u.goals[k] = v
+		}
+	}
+}
+
+// AddProduct adds or replaces a product in the food database.
+func (s *Server) AddProduct(p Product) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.products[p.ID] = normalize(p)
+}
+
+// AddRecipe adds or replaces a recipe.
+func (s *Server) AddRecipe(r Recipe) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.recipes[r.ID] = normalize(r)
+}
+
+// normalize fills in the fields the real API always returns, so responses
+// match yazio-api.yaml.
+func normalize(p Product) Product {
+	if p.Servings == nil {
+		p.Servings = []Serving{}
+	}
+	if p.EANs == nil {
+		p.EANs = []string{}
+	}
+	if p.Countries == nil {
+		p.Countries = []string{}
+	}
+	if p.Language == "" {
+		p.Language = "en"
+	}
+	if p.UpdatedAt == "" {
+		p.UpdatedAt = "2024-01-01T00:00:00+00:00"
+	}
+	return p
+}
+
+// IssueTokens logs email in without a password and returns a token pair.
+func (s *Server) IssueTokens(email string) (accessToken, refreshToken string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.issueLocked(email)
+}
+
+// ExpireAccessTokens invalidates every access token, so the next request gets
+// a 401 and the client has to use its refresh token.
+func (s *Server) ExpireAccessTokens() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.access = map[string]string{}
+}
+
+// Consumed returns a copy of a user's diary entries for date (YYYY-MM-DD).
+func (s *Server) Consumed(email, date string) ([]ConsumedProduct, []ConsumedRecipe) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u := s.users[email]
+	if u == nil {
+		return nil, nil
+	}
+	return filterDate(u.products, date), filterRecipeDate(u.recipes, date)
+}
+
+func (s *Server) issueLocked(email string) (string, string) {
+	at, rt := newToken(), newToken()
+	s.access[at] = email
+	s.refresh[rt] = email
+	return at, rt
+}
+
+// Handler returns the HTTP handler serving the fake API under /v15.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+prefix+"/oauth/token", s.handleToken)
+	mux.HandleFunc("GET "+prefix+"/user", s.authed(s.handleProfile))
+	mux.HandleFunc("GET "+prefix+"/user/goals", s.authed(s.handleGoals))
+	mux.HandleFunc("GET "+prefix+"/user/consumed-items", s.authed(s.handleConsumedGet))
+	mux.HandleFunc("POST "+prefix+"/user/consumed-items", s.authed(s.handleConsumedPost))
+	mux.HandleFunc("DELETE "+prefix+"/user/consumed-items", s.authed(s.handleConsumedDelete))
+	mux.HandleFunc("DELETE "+prefix+"/user/consumed-items/{id}", s.authed(func(w http.ResponseWriter, r *http.Request, u *user) {
+		// Mirrors the real API, which only accepts IDs in the body.
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}))
+	mux.HandleFunc("GET "+prefix+"/user/consumed-items/nutrients-daily", s.authed(s.handleNutrientsDaily))
+	mux.HandleFunc("GET "+prefix+"/products/search", s.authed(s.handleSearch))
+	mux.HandleFunc("GET "+prefix+"/products/{id}", s.authed(s.handleProduct))
+	mux.HandleFunc("GET "+prefix+"/recipes/{id}", s.authed(s.handleRecipe))
+	return mux
+}
+
+type authedHandler func(w http.ResponseWriter, r *http.Request, u *user)
+
+// authed checks the bearer token and passes the user to h with s.mu held.
+func (s *Server) authed(h authedHandler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		email := s.access[token]
+		u := s.users[email]
+		if !ok || u == nil {
+			writeError(w, http.StatusUnauthorized, "invalid_token")
+			return
+		}
+		h(w, r, u)
+	}
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ClientID     string `json:"client_id"`
+		ClientSecret string `json:"client_secret"`
+		GrantType    string `json:"grant_type"`
+		Username     string `json:"username"`
+		Password     string `json:"password"`
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request")
+		return
+	}
+	if req.ClientID == "" || req.ClientSecret == "" {
+		writeError(w, http.StatusBadRequest, "invalid_client")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var email string
+	switch req.GrantType {
+	case "password":
+		u := s.users[req.Username]
+		if u == nil || u.password != req.Password {
+			writeError(w, http.StatusUnauthorized, "invalid_grant")
+			return
+		}
+		email = req.Username
+	case "refresh_token":
+		var ok bool
+		email, ok = s.refresh[req.RefreshToken]
+		if !ok {
+			writeError(w, http.StatusUnauthorized, "invalid_grant")
+			return
+		}
+		// Refresh tokens rotate: the old one is single-use.
+		delete(s.refresh, req.RefreshToken)
+	default:
+		writeError(w, http.StatusBadRequest, "unsupported_grant_type")
+		return
+	}
+
+	at, rt := s.issueLocked(email)
+	writeJSON(w, map[string]any{
+		"access_token":  at,
+		"refresh_token": rt,
+		"expires_in":    int(AccessTokenLifetime.Seconds()),
+		"token_type":    "bearer",
+	})
+}
+
+func (s *Server) handleProfile(w http.ResponseWriter, r *http.Request, u *user) {
+	writeJSON(w, u.profile)
+}
+
+func (s *Server) handleGoals(w http.ResponseWriter, r *http.Request, u *user) {
+	if _, ok := queryDate(w, r, "date"); !ok {
+		return
+	}
+	writeJSON(w, u.goals)
+}
+
+func (s *Server) handleConsumedGet(w http.ResponseWriter, r *http.Request, u *user) {
+	date, ok := queryDate(w, r, "date")
+	if !ok {
+		return
+	}
+	writeJSON(w, map[string]any{
+		"products":        nonNil(filterDate(u.products, date)),
+		"recipe_portions": nonNilRecipes(filterRecipeDate(u.recipes, date)),
+		"simple_products": []any{},
+	})
+}
+
+func (s *Server) handleConsumedPost(w http.ResponseWriter, r *http.Request, u *user) {
+	var req struct {
+		Products       *[]ConsumedProduct `json:"products"`
+		RecipePortions *[]ConsumedRecipe  `json:"recipe_portions"`
+		SimpleProducts *[]json.RawMessage `json:"simple_products"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid json: "+err.Error())
+		return
+	}
+	if req.Products == nil || req.RecipePortions == nil || req.SimpleProducts == nil {
+		writeError(w, http.StatusBadRequest, "products, recipe_portions and simple_products are required")
+		return
+	}
+	for _, p := range *req.Products {
+		if p.ID == "" || p.ProductID == "" || !validDaytime(p.Daytime) || !validDate(p.Date) || p.Serving == "" {
+			writeError(w, http.StatusBadRequest, "invalid product entry")
+			return
+		}
+		if _, ok := s.products[p.ProductID]; !ok {
+			writeError(w, http.StatusBadRequest, "unknown product "+p.ProductID)
+			return
+		}
+	}
+	for _, rp := range *req.RecipePortions {
+		if rp.ID == "" || rp.RecipeID == "" || !validDaytime(rp.Daytime) || !validDate(rp.Date) || rp.PortionCount <= 0 {
+			writeError(w, http.StatusBadRequest, "invalid recipe portion")
+			return
+		}
+		if _, ok := s.recipes[rp.RecipeID]; !ok {
+			writeError(w, http.StatusBadRequest, "unknown recipe "+rp.RecipeID)
+			return
+		}
+	}
+	for _, p := range *req.Products {
+		p.Type = "product"
+		u.products = append(u.products, p)
+	}
+	u.recipes = append(u.recipes, *req.RecipePortions...)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleConsumedDelete(w http.ResponseWriter, r *http.Request, u *user) {
+	var ids []string
+	if err := json.NewDecoder(r.Body).Decode(&ids); err != nil {
+		writeError(w, http.StatusBadRequest, "expected a JSON array of ids")
+		return
+	}
+	del := map[string]bool{}
+	for _, id := range ids {
+		del[id] = true
+	}
+	products := u.products[:0]
+	for _, p := range u.products {
+		if !del[p.ID] {
+			products = append(products, p)
+		}
+	}
+	u.products = products
+	recipes := u.recipes[:0]
+	for _, rp := range u.recipes {
+		if !del[rp.ID] {
+			recipes = append(recipes, rp)
+		}
+	}
+	u.recipes = recipes
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleNutrientsDaily(w http.ResponseWriter, r *http.Request, u *user) {
+	start, ok := queryDate(w, r, "start")
+	if !ok {
+		return
+	}
+	end, ok := queryDate(w, r, "end")
+	if !ok {
+		return
+	}
+
+	type day struct {
+		Date       string  `json:"date"`
+		Energy     float64 `json:"energy"`
+		Carb       float64 `json:"carb"`
+		Protein    float64 `json:"protein"`
+		Fat        float64 `json:"fat"`
+		EnergyGoal float64 `json:"energy_goal"`
+	}
+	days := map[string]*day{}
+	add := func(date string, n map[string]float64, factor float64) {
+		if date < start || date > end {
+			return
+		}
+		d := days[date]
+		if d == nil {
+			d = &day{Date: date, EnergyGoal: u.goals["energy.energy"]}
+			days[date] = d
+		}
+		d.Energy += n["energy.energy"] * factor
+		d.Carb += n["nutrient.carb"] * factor
+		d.Protein += n["nutrient.protein"] * factor
+		d.Fat += n["nutrient.fat"] * factor
+	}
+	for _, p := range u.products {
+		add(p.Date, s.products[p.ProductID].Nutrients, p.Amount)
+	}
+	for _, rp := range u.recipes {
+		add(rp.Date, s.recipes[rp.RecipeID].Nutrients, rp.PortionCount)
+	}
+
+	out := []day{}
+	for _, d := range days {
+		d.Energy = round1(d.Energy)
+		d.Carb = round1(d.Carb)
+		d.Protein = round1(d.Protein)
+		d.Fat = round1(d.Fat)
+		out = append(out, *d)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Date < out[j].Date })
+	writeJSON(w, out)
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request, u *user) {
+	q := r.URL.Query()
+	for _, k := range []string{"query", "language", "countries", "sex"} {
+		if q.Get(k) == "" {
+			writeError(w, http.StatusBadRequest, "missing "+k)
+			return
+		}
+	}
+	query := strings.ToLower(q.Get("query"))
+	country := strings.ToUpper(q.Get("countries"))
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	offset, _ := strconv.Atoi(q.Get("offset"))
+
+	type item struct {
+		ProductID     string   `json:"product_id"`
+		Name          string   `json:"name"`
+		Score         float64  `json:"score"`
+		Serving       string   `json:"serving"`
+		Producer      *string  `json:"producer"`
+		Energy        float64  `json:"energy"`
+		Carbohydrates float64  `json:"carbohydrates"`
+		Protein       float64  `json:"protein"`
+		Fat           float64  `json:"fat"`
+		Countries     []string `json:"countries"`
+		Language      string   `json:"language"`
+		IsVerified    bool     `json:"is_verified"`
+	}
+	var items []item
+	for _, p := range s.products {
+		name := strings.ToLower(p.Name)
+		if !strings.Contains(name, query) {
+			continue
+		}
+		if len(p.Countries) > 0 && !contains(p.Countries, country) {
+			continue
+		}
+		it := item{
+			ProductID:     p.ID,
+			Name:          p.Name,
+			Score:         float64(len(query)) / float64(len(name)),
+			Serving:       "gram",
+			Energy:        round1(p.Nutrients["energy.energy"] * 100),
+			Carbohydrates: round1(p.Nutrients["nutrient.carb"] * 100),
+			Protein:       round1(p.Nutrients["nutrient.protein"] * 100),
+			Fat:           round1(p.Nutrients["nutrient.fat"] * 100),
+			Countries:     p.Countries,
+			Language:      p.Language,
+			IsVerified:    p.IsVerified,
+		}
+		it.Producer = p.Producer
+		if len(p.Servings) > 0 {
+			it.Serving = p.Servings[0].Serving
+		}
+		items = append(items, it)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Score != items[j].Score {
+			return items[i].Score > items[j].Score
+		}
+		return items[i].Name < items[j].Name
+	})
+	if offset > len(items) {
+		offset = len(items)
+	}
+	items = items[offset:]
+	if limit > 0 && limit < len(items) {
+		items = items[:limit]
+	}
+	if items == nil {
+		items = []item{}
+	}
+	writeJSON(w, items)
+}
+
+func (s *Server) handleProduct(w http.ResponseWriter, r *http.Request, u *user) {
+	p, ok := s.products[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "product not found")
+		return
+	}
+	writeJSON(w, p)
+}
+
+func (s *Server) handleRecipe(w http.ResponseWriter, r *http.Request, u *user) {
+	rc, ok := s.recipes[r.PathValue("id")]
+	if !ok {
+		writeError(w, http.StatusNotFound, "recipe not found")
+		return
+	}
+	writeJSON(w, rc)
+}
+
+func queryDate(w http.ResponseWriter, r *http.Request, key string) (string, bool) {
+	v := r.URL.Query().Get(key)
+	if !validDate(v) {
+		writeError(w, http.StatusBadRequest, "invalid or missing "+key)
+		return "", false
+	}
+	return v, true
+}
+
+func validDate(s string) bool {
+	_, err := time.Parse(time.DateOnly, s)
+	return err == nil
+}
+
+func validDaytime(s string) bool {
+	switch s {
+	case "breakfast", "lunch", "dinner", "snack":
+		return true
+	}
+	return false
+}
+
+func filterDate(items []ConsumedProduct, date string) []ConsumedProduct {
+	var out []ConsumedProduct
+	for _, p := range items {
+		if p.Date == date {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+func filterRecipeDate(items []ConsumedRecipe, date string) []ConsumedRecipe {
+	var out []ConsumedRecipe
+	for _, rp := range items {
+		if rp.Date == date {
+			out = append(out, rp)
+		}
+	}
+	return out
+}
+
+func nonNil(p []ConsumedProduct) []ConsumedProduct {
+	if p == nil {
+		return []ConsumedProduct{}
+	}
+	return p
+}
+
+func nonNilRecipes(r []ConsumedRecipe) []ConsumedRecipe {
+	if r == nil {
+		return []ConsumedRecipe{}
+	}
+	return r
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func round1(f float64) float64 {
+	return math.Round(f*10) / 10
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
+
+func newToken() string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	h := hex.EncodeToString(b)
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
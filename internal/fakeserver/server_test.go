@@ -0,0 +1,139 @@
+package fakeserver_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/koriwi/yazio-cli/internal/api"
+	"github.com/koriwi/yazio-cli/internal/fakeserver"
+	"github.com/koriwi/yazio-cli/internal/models"
+)
+
+func start(t *testing.T) *fakeserver.Server {
+	t.Helper()
+	srv := fakeserver.Start()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newClient(srv *fakeserver.Server, token string) *api.Client {
+	c := api.New(token)
+	c.SetBaseURL(srv.URL())
+	return c
+}
+
+func TestLoginAndRefresh(t *testing.T) {
+	srv := start(t)
+	c := newClient(srv, "")
+
+	if _, err := c.Login(fakeserver.DefaultEmail, "wrong"); err == nil {
+		t.Fatal("login with a wrong password succeeded")
+	}
+	login, err := c.Login(fakeserver.DefaultEmail, fakeserver.DefaultPassword)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if login.AccessToken == "" || login.RefreshToken == "" {
+		t.Fatalf("login returned %+v", login)
+	}
+
+	refreshed, err := c.RefreshAccessToken(login.RefreshToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if refreshed.RefreshToken == login.RefreshToken || refreshed.AccessToken == login.AccessToken {
+		t.Error("refresh did not rotate the tokens")
+	}
+
+	// The old refresh token is single-use
+	_, err = c.RefreshAccessToken(login.RefreshToken)
+	var httpErr *api.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.Status != 401 {
+		t.Errorf("reusing the old refresh token: err = %v, want HTTP 401", err)
+	}
+	if _, err := c.RefreshAccessToken(refreshed.RefreshToken); err != nil {
+		t.Errorf("the new refresh token was rejected: %v", err)
+	}
+}
+
+func TestRefreshOn401(t *testing.T) {
+	srv := start(t)
+	access, refresh := srv.IssueTokens(fakeserver.DefaultEmail)
+	c := newClient(srv, access)
+	var saved []string
+	c.SetRefresh(refresh, func(accessToken, refreshToken string) {
+		saved = append(saved, accessToken, refreshToken)
+	})
+
+	if _, err := c.GetProfile(); err != nil {
+		t.Fatal(err)
+	}
+	srv.ExpireAccessTokens()
+	profile, err := c.GetProfile()
+	if err != nil {
+		t.Fatalf("request after expiry: %v", err)
+	}
+	if profile.Email != fakeserver.DefaultEmail {
+		t.Errorf("profile email = %q", profile.Email)
+	}
+	if len(saved) != 2 || saved[0] == access || saved[1] == refresh {
+		t.Errorf("onRefresh got %q, want a new token pair", saved)
+	}
+
+	// Without a usable refresh token the session is over
+	srv.ExpireAccessTokens()
+	c = newClient(srv, saved[0])
+	c.SetRefresh(refresh, nil) // already rotated away
+	if _, err := c.GetProfile(); !errors.Is(err, api.ErrSessionExpired) {
+		t.Errorf("err = %v, want ErrSessionExpired", err)
+	}
+}
+
+func TestConsumedItems(t *testing.T) {
+	srv := start(t)
+	access, _ := srv.IssueTokens(fakeserver.DefaultEmail)
+	c := newClient(srv, access)
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.Local)
+
+	err := c.AddConsumedItems([]models.AddConsumedRequest{
+		{ID: "p-1", ProductID: fakeserver.ProductOats, Date: "2024-01-15", Daytime: "breakfast", Amount: 80, Serving: "gram", ServingQuantity: 80, Type: "product"},
+		{ID: "r-1", ProductID: fakeserver.RecipePorridge, Date: "2024-01-15", Daytime: "breakfast", ServingQuantity: 1.5, Type: "recipe_portion"},
+		{ID: "p-2", ProductID: fakeserver.ProductBanana, Date: "2024-01-16", Daytime: "snack", Amount: 120, Serving: "piece", ServingQuantity: 1, Type: "product"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := c.GetConsumedItems(date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Products) != 1 || got.Products[0].ID != "p-1" || got.Products[0].Amount != 80 {
+		t.Errorf("products = %+v, want only p-1 with 80 g", got.Products)
+	}
+	if len(got.RecipePortions) != 1 || got.RecipePortions[0].ID != "r-1" || got.RecipePortions[0].PortionCount != 1.5 {
+		t.Errorf("recipe portions = %+v, want r-1 with 1.5 portions", got.RecipePortions)
+	}
+
+	if err := c.DeleteConsumedItems([]string{"p-1", "r-1"}); err != nil {
+		t.Fatal(err)
+	}
+	got, err = c.GetConsumedItems(date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Products)+len(got.RecipePortions) != 0 {
+		t.Errorf("after delete: %+v", got)
+	}
+	if products, _ := srv.Consumed(fakeserver.DefaultEmail, "2024-01-16"); len(products) != 1 {
+		t.Errorf("other day has %d products, want 1", len(products))
+	}
+
+	// Unknown products are rejected like the real API does
+	err = c.AddConsumedItem(models.AddConsumedRequest{ProductID: "nope", Date: "2024-01-15", Daytime: "lunch", Amount: 1, Serving: "gram", ServingQuantity: 1})
+	var httpErr *api.HTTPError
+	if !errors.As(err, &httpErr) || httpErr.Status != 400 {
+		t.Errorf("unknown product: err = %v, want HTTP 400", err)
+	}
+}
@@ -0,0 +1,56 @@
+package models
+
+import "fmt"
+
+// ProductServings returns the servings the product defines, always including gram.
+func ProductServings(p *ProductResponse) []Serving {
+	if p == nil || len(p.Servings) == 0 {
+		return []Serving{{Amount: 1, Serving: "gram"}}
+	}
+	for _, s := range p.Servings {
+		if s.Serving == "gram" {
+			return p.Servings
+		}
+	}
+	return append(p.Servings, Serving{Amount: 1, Serving: "gram"})
+}
+
+// DefaultServingAmount returns the default quantity string for a serving.
+func DefaultServingAmount(s Serving) string {
+	if s.Serving == "gram" {
+		return "100"
+	}
+	return "1"
+}
+
+// ServingLabel returns a short user-facing label for a serving.
+func ServingLabel(s Serving) string {
+	if s.Serving == "gram" {
+		return "g"
+	}
+	return s.Serving
+}
+
+// ServingGrams converts a quantity of a serving to total grams.
+func ServingGrams(qty float64, s Serving) float64 {
+	return qty * s.Amount // gram.Amount == 1, so gram-based is a no-op
+}
+
+// FormatServing describes an amount for display, e.g. "150g" or "2 cookies".
+func FormatServing(amountGrams float64, serving string, qty float64) string {
+	switch serving {
+	case "gram", "g", "":
+		return fmt.Sprintf("%.0fg", amountGrams)
+	case "ml":
+		return fmt.Sprintf("%.0fml", amountGrams)
+	default:
+		// Show serving count + label (e.g. "2 cookies", "1 package")
+		if qty <= 0 {
+			qty = 1
+		}
+		if qty == 1 {
+			return fmt.Sprintf("1 %s", serving)
+		}
+		return fmt.Sprintf("%.0f %ss", qty, serving)
+	}
+}
@@ -31,11 +31,20 @@ type ConsumedRecipe struct {
 // ProductResponse is the response from GET /v9/products/{id}
 // Nutrients use dotted keys ("energy.energy", "nutrient.carb", …) and are per gram.
 type ProductResponse struct {
-	ID       string
-	Name     string
-	Nutrients ProductNutrients
-	Servings  []Serving
-	BaseUnit  string
+	ID         string
+	Name       string
+	Producer   string // brand, empty for generic foods
+	IsVerified bool   // checked by YAZIO
+	Nutrients  ProductNutrients
+	Micros     map[string]float64 // other nutrients (vitamins, minerals, …) by dotted key, per gram
+	Servings   []Serving
+	BaseUnit   string
+}
+
+// mainNutrients are the nutrient keys with a field in ProductNutrients.
+var mainNutrients = map[string]bool{
+	"energy.energy": true, "nutrient.carb": true, "nutrient.protein": true, "nutrient.fat": true,
+	"nutrient.sugar": true, "nutrient.saturated": true, "nutrient.salt": true,
 }
 
 func (p *ProductResponse) UnmarshalJSON(data []byte) error {
@@ -43,6 +52,8 @@ func (p *ProductResponse) UnmarshalJSON(data []byte) error {
 		ID        string             `json:"id"`
 		ProductID string             `json:"product_id"` // search results use this instead of id
 		Name      string             `json:"name"`
+		Producer  *string            `json:"producer"` // null for generic foods
+		Verified  bool               `json:"is_verified"`
 		Nutrients map[string]float64 `json:"nutrients"`
 		// Search result flat nutrient fields (per 100g)
 		Energy        float64 `json:"energy"`
@@ -63,6 +74,10 @@ func (p *ProductResponse) UnmarshalJSON(data []byte) error {
 		p.ID = raw.ProductID // fallback for search results
 	}
 	p.Name = raw.Name
+	if raw.Producer != nil {
+		p.Producer = *raw.Producer
+	}
+	p.IsVerified = raw.Verified
 	p.BaseUnit = raw.BaseUnit
 	if len(raw.Nutrients) > 0 {
 		p.Nutrients = ProductNutrients{
@@ -74,6 +89,14 @@ func (p *ProductResponse) UnmarshalJSON(data []byte) error {
 			Saturated:  raw.Nutrients["nutrient.saturated"],
 			Salt:       raw.Nutrients["nutrient.salt"],
 		}
+		for k, v := range raw.Nutrients {
+			if !mainNutrients[k] {
+				if p.Micros == nil {
+					p.Micros = map[string]float64{}
+				}
+				p.Micros[k] = v
+			}
+		}
 	} else {
 		// Search results return flat per-100g fields; convert to per gram
 		p.Nutrients = ProductNutrients{
@@ -104,8 +127,8 @@ type ProductNutrients struct {
 }
 
 type Serving struct {
-	Amount  float64 // grams per serving
-	Serving string  // serving name ("cookie", "package", …)
+	Amount  float64 `json:"amount"`  // grams per serving
+	Serving string  `json:"serving"` // serving name ("cookie", "package", …)
 }
 
 // DailyNutrient is one entry from GET /v9/user/consumed-items/nutrients-daily?start=...&end=...
@@ -122,11 +145,11 @@ type DailyNutrient struct {
 // GoalsResponse holds parsed goals from GET /v9/user/goals?date=...
 // The raw JSON uses dotted keys like "energy.energy", "nutrient.carb" — parsed in api/client.go
 type GoalsResponse struct {
-	EnergyKcal float64
-	Carb       float64
-	Protein    float64
-	Fat        float64
-	Water      float64
+	EnergyKcal float64 `json:"energy_kcal"`
+	Carb       float64 `json:"carb"`
+	Protein    float64 `json:"protein"`
+	Fat        float64 `json:"fat"`
+	Water      float64 `json:"water"` // ml
 }
 
 // ProductSearchResponse is the response from GET /v9/products/search?query=...
@@ -136,39 +159,71 @@ type ProductSearchResponse struct {
 
 // UserProfile is the response from GET /v9/user
 type UserProfile struct {
-	UUID      string `json:"uuid"`
-	Email     string `json:"email"`
-	FirstName string `json:"first_name"`
-	LastName  string `json:"last_name"`
-	Country   string `json:"country"`
-	Sex       string `json:"sex"`
-	Language  string `json:"language"`
+	UUID                string `json:"uuid"`
+	Email               string `json:"email"`
+	FirstName           string `json:"first_name"`
+	LastName            string `json:"last_name"`
+	Country             string `json:"country"`
+	Sex                 string `json:"sex"`
+	Language            string `json:"language"`
+	FoodDatabaseCountry string `json:"food_database_country"`
+}
+
+// SearchRequest holds the query parameters for GET /v9/products/search.
+// Language, Country and Sex are all required by the API; use NewSearchRequest
+// to fill them from the user's profile.
+type SearchRequest struct {
+	Query    string
+	Language string // e.g. "en"
+	Country  string // food database country, e.g. "DE"
+	Sex      string // "male" or "female"
+	Limit    int    // page size, 0 = API default
+	Offset   int    // number of results to skip
+}
+
+// NewSearchRequest builds a search request for query using the profile's
+// language, food database country and sex. There are no defaults: without a
+// profile, callers must not guess the locale.
+func NewSearchRequest(query string, profile UserProfile) SearchRequest {
+	req := SearchRequest{Query: query, Language: profile.Language, Country: profile.FoodDatabaseCountry, Sex: "male"}
+	if req.Country == "" {
+		req.Country = profile.Country
+	}
+	// The search endpoint only accepts male/female.
+	if profile.Sex == "female" {
+		req.Sex = "female"
+	}
+	return req
 }
 
 // AddConsumedRequest is the body for POST /v9/user/consumed-items
 type AddConsumedRequest struct {
+	ID              string  `json:"id"` // consumed item ID; a new one is generated when empty
 	ProductID       string  `json:"product_id"`
 	Date            string  `json:"date"`
 	Daytime         string  `json:"daytime"`
 	Amount          float64 `json:"amount"`
 	Serving         string  `json:"serving"`
 	ServingQuantity float64 `json:"serving_quantity"`
-	Type            string  `json:"type"`
+	Type            string  `json:"type"` // "product" or "recipe_portion" (ServingQuantity = portions)
 }
 
-// DiaryEntry is a resolved consumed item with product name and nutrients
+// DiaryEntry is a resolved consumed item with product name and nutrients.
+// The JSON tags are the schema of `yazio-cli diary --output json`.
 type DiaryEntry struct {
-	ConsumedID      string
-	ProductID       string
-	Name            string
-	MealTime        string // populated from Daytime
-	Amount          float64
-	Serving         string
-	ServingQuantity float64
-	Kcal            float64
-	Protein         float64
-	Carbs           float64
-	Fat             float64
+	ConsumedID      string  `json:"consumed_id"`
+	ProductID       string  `json:"product_id"`
+	Name            string  `json:"name"`
+	MealTime        string  `json:"meal_time"` // populated from Daytime
+	Amount          float64 `json:"amount"`    // grams, or portions for recipes
+	Serving         string  `json:"serving"`
+	ServingQuantity float64 `json:"serving_quantity"`
+	Kcal            float64 `json:"kcal"`
+	Protein         float64 `json:"protein"`
+	Carbs           float64 `json:"carbs"`
+	Fat             float64 `json:"fat"`
+	Recipe          bool    `json:"recipe,omitempty"` // ProductID is a recipe and Amount is portions
+	Err             string  `json:"error,omitempty"`  // set when the product/recipe could not be loaded; Name and nutrients are empty
 }
 
 func MealTimeLabel(mealTime string) string {
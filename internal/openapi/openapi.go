@@ -0,0 +1,257 @@
+// Package openapi validates JSON responses against the response schemas in
+// yazio-api.yaml. It understands the subset of OpenAPI 3 used there: $ref to
+// components/schemas, object properties and additionalProperties, arrays,
+// primitive types, enum and nullable.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Spec is a parsed OpenAPI document.
+type Spec struct {
+	root map[string]any
+}
+
+// Parse reads an OpenAPI document in YAML (or JSON) form.
+func Parse(data []byte) (*Spec, error) {
+	var root map[string]any
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("openapi: %w", err)
+	}
+	if _, ok := root["paths"].(map[string]any); !ok {
+		return nil, fmt.Errorf("openapi: document has no paths")
+	}
+	return &Spec{root: root}, nil
+}
+
+// Schema is a node of a JSON schema from the document.
+type Schema map[string]any
+
+// ResponseSchema returns the JSON schema documented for a response, e.g.
+// ResponseSchema("GET", "/products/{productId}", 200).
+func (s *Spec) ResponseSchema(method, path string, status int) (Schema, error) {
+	paths, _ := s.root["paths"].(map[string]any)
+	item, ok := paths[path].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("openapi: path %s not documented", path)
+	}
+	op, ok := item[strings.ToLower(method)].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("openapi: %s %s not documented", method, path)
+	}
+	responses, _ := op["responses"].(map[string]any)
+	resp, ok := responses[strconv.Itoa(status)].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("openapi: %s %s has no %d response", method, path, status)
+	}
+	content, _ := resp["content"].(map[string]any)
+	media, _ := content["application/json"].(map[string]any)
+	schema, ok := media["schema"].(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("openapi: %s %s %d has no JSON schema", method, path, status)
+	}
+	return s.resolve(schema), nil
+}
+
+// resolve follows $ref pointers of the form #/components/schemas/Name.
+func (s *Spec) resolve(node map[string]any) Schema {
+	for i := 0; i < 16; i++ {
+		ref, ok := node["$ref"].(string)
+		if !ok {
+			break
+		}
+		target := s.lookup(ref)
+		if target == nil {
+			break
+		}
+		node = target
+	}
+	return Schema(node)
+}
+
+func (s *Spec) lookup(ref string) map[string]any {
+	parts := strings.Split(strings.TrimPrefix(ref, "#/"), "/")
+	var cur any = s.root
+	for _, p := range parts {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil
+		}
+		cur = m[p]
+	}
+	m, _ := cur.(map[string]any)
+	return m
+}
+
+// IssueKind classifies a difference between a response and its schema.
+type IssueKind string
+
+const (
+	UnknownField IssueKind = "unknown field"
+	MissingField IssueKind = "missing field"
+	TypeChanged  IssueKind = "type changed"
+	EnumChanged  IssueKind = "unknown enum value"
+)
+
+// Issue is one difference. Path uses $.a.b[] notation, with array indexes
+// collapsed so the same problem in every element is reported once.
+type Issue struct {
+	Kind   IssueKind
+	Path   string
+	Detail string
+}
+
+func (i Issue) String() string {
+	if i.Detail == "" {
+		return fmt.Sprintf("%-18s %s", i.Kind, i.Path)
+	}
+	return fmt.Sprintf("%-18s %s (%s)", i.Kind, i.Path, i.Detail)
+}
+
+// Validate checks a JSON body against schema and returns the differences,
+// sorted by path.
+func (s *Spec) Validate(schema Schema, body []byte) ([]Issue, error) {
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return nil, fmt.Errorf("response is not JSON: %w", err)
+	}
+	seen := map[Issue]bool{}
+	var issues []Issue
+	report := func(i Issue) {
+		if !seen[i] {
+			seen[i] = true
+			issues = append(issues, i)
+		}
+	}
+	s.validate(schema, v, "$", report)
+	sort.Slice(issues, func(a, b int) bool {
+		if issues[a].Path != issues[b].Path {
+			return issues[a].Path < issues[b].Path
+		}
+		return issues[a].Kind < issues[b].Kind
+	})
+	return issues, nil
+}
+
+func (s *Spec) validate(schema Schema, v any, path string, report func(Issue)) {
+	if v == nil {
+		if nullable, _ := schema["nullable"].(bool); !nullable && schema["type"] != nil {
+			report(Issue{Kind: TypeChanged, Path: path, Detail: fmt.Sprintf("expected %v, got null", schema["type"])})
+		}
+		return
+	}
+
+	want, _ := schema["type"].(string)
+	if want == "" {
+		// Untyped schemas ({}), or objects described only by properties
+		if _, ok := schema["properties"]; ok {
+			want = "object"
+		} else if _, ok := schema["additionalProperties"]; ok {
+			want = "object"
+		} else {
+			return
+		}
+	}
+	got := jsonType(v)
+	if !typeMatches(want, got, v) {
+		report(Issue{Kind: TypeChanged, Path: path, Detail: fmt.Sprintf("expected %s, got %s", want, got)})
+		return
+	}
+
+	if enum, ok := schema["enum"].([]any); ok && !enumContains(enum, v) {
+		report(Issue{Kind: EnumChanged, Path: path, Detail: fmt.Sprintf("%v", v)})
+	}
+
+	switch want {
+	case "object":
+		obj := v.(map[string]any)
+		props, _ := schema["properties"].(map[string]any)
+		for name, ps := range props {
+			child, ok := obj[name]
+			if !ok {
+				report(Issue{Kind: MissingField, Path: path + "." + name})
+				continue
+			}
+			if m, ok := ps.(map[string]any); ok {
+				s.validate(s.resolve(m), child, path+"."+name, report)
+			}
+		}
+		var additional Schema
+		allowAdditional := false
+		switch ap := schema["additionalProperties"].(type) {
+		case bool:
+			allowAdditional = ap
+		case map[string]any:
+			allowAdditional = true
+			additional = s.resolve(ap)
+		}
+		for name, child := range obj {
+			if _, ok := props[name]; ok {
+				continue
+			}
+			if !allowAdditional {
+				report(Issue{Kind: UnknownField, Path: path + "." + name, Detail: jsonType(child)})
+				continue
+			}
+			if additional != nil {
+				s.validate(additional, child, path+"."+name, report)
+			}
+		}
+	case "array":
+		items, _ := schema["items"].(map[string]any)
+		if items == nil {
+			return
+		}
+		is := s.resolve(items)
+		for _, child := range v.([]any) {
+			s.validate(is, child, path+"[]", report)
+		}
+	}
+}
+
+func jsonType(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == float64(int64(v)) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func typeMatches(want, got string, v any) bool {
+	switch want {
+	case "number":
+		return got == "number" || got == "integer"
+	case "integer":
+		return got == "integer"
+	}
+	return want == got
+}
+
+func enumContains(enum []any, v any) bool {
+	for _, e := range enum {
+		if fmt.Sprint(e) == fmt.Sprint(v) {
+			return true
+		}
+	}
+	return false
+}
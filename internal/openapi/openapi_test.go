@@ -0,0 +1,204 @@
+package openapi
+
+import (
+	"os"
+	"testing"
+)
+
+const testSpec = `
+openapi: 3.0.3
+paths:
+  /items/{id}:
+    get:
+      responses:
+        '200':
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/Item'
+        '404':
+          description: Not found
+  /totals:
+    get:
+      responses:
+        '200':
+          content:
+            application/json:
+              schema:
+                type: object
+                additionalProperties:
+                  type: number
+components:
+  schemas:
+    Item:
+      type: object
+      properties:
+        id: {type: string}
+        count: {type: integer}
+        weight: {type: number}
+        unit:
+          type: string
+          enum: [gram, ml]
+        note:
+          type: string
+          nullable: true
+        tags:
+          type: array
+          items: {type: string}
+        servings:
+          type: array
+          items:
+            $ref: '#/components/schemas/Serving'
+        extra: {}
+    Serving:
+      $ref: '#/components/schemas/ServingObject'
+    ServingObject:
+      properties:
+        serving: {type: string}
+        amount: {type: number}
+`
+
+func parse(t *testing.T) *Spec {
+	t.Helper()
+	spec, err := Parse([]byte(testSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return spec
+}
+
+func TestValidate(t *testing.T) {
+	spec := parse(t)
+	item, err := spec.ResponseSchema("GET", "/items/{id}", 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	totals, err := spec.ResponseSchema("get", "/totals", 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const valid = `"id":"a","count":2,"weight":1,"unit":"gram","note":null,"tags":[],"servings":[{"serving":"cup","amount":0.5}],"extra":[1]`
+	tests := []struct {
+		name   string
+		schema Schema
+		body   string
+		want   []Issue
+	}{
+		{name: "matches", schema: item, body: `{` + valid + `}`},
+		{
+			name:   "unknown field",
+			schema: item,
+			body:   `{` + valid + `,"color":"red"}`,
+			want:   []Issue{{Kind: UnknownField, Path: "$.color", Detail: "string"}},
+		},
+		{
+			name:   "missing fields",
+			schema: item,
+			body:   `{"id":"a","count":2,"weight":1,"unit":"gram","note":null,"tags":[]}`,
+			want:   []Issue{{Kind: MissingField, Path: "$.extra"}, {Kind: MissingField, Path: "$.servings"}},
+		},
+		{
+			name:   "integer is a number but not the other way round",
+			schema: item,
+			body:   `{"id":"a","count":2.5,"weight":3,"unit":"gram","note":null,"tags":[],"servings":[],"extra":0}`,
+			want:   []Issue{{Kind: TypeChanged, Path: "$.count", Detail: "expected integer, got number"}},
+		},
+		{
+			name:   "null only where nullable",
+			schema: item,
+			body:   `{"id":null,"count":2,"weight":1,"unit":"gram","note":null,"tags":[],"servings":[],"extra":null}`,
+			want:   []Issue{{Kind: TypeChanged, Path: "$.id", Detail: "expected string, got null"}},
+		},
+		{
+			name:   "enum",
+			schema: item,
+			body:   `{"id":"a","count":2,"weight":1,"unit":"piece","note":"","tags":[],"servings":[],"extra":0}`,
+			want:   []Issue{{Kind: EnumChanged, Path: "$.unit", Detail: "piece"}},
+		},
+		{
+			name:   "array elements are reported once",
+			schema: item,
+			body: `{"id":"a","count":2,"weight":1,"unit":"ml","note":null,"tags":["x",1,2],
+				"servings":[{"serving":"cup","amount":"1"},{"serving":"cup","amount":"2","size":1}],"extra":0}`,
+			want: []Issue{
+				{Kind: TypeChanged, Path: "$.servings[].amount", Detail: "expected number, got string"},
+				{Kind: UnknownField, Path: "$.servings[].size", Detail: "integer"},
+				{Kind: TypeChanged, Path: "$.tags[]", Detail: "expected string, got integer"},
+			},
+		},
+		{
+			name:   "wrong top-level type",
+			schema: item,
+			body:   `[]`,
+			want:   []Issue{{Kind: TypeChanged, Path: "$", Detail: "expected object, got array"}},
+		},
+		{name: "additionalProperties", schema: totals, body: `{"energy.energy":2000,"water":1.5}`},
+		{
+			name:   "additionalProperties with the wrong type",
+			schema: totals,
+			body:   `{"energy.energy":"2000"}`,
+			want:   []Issue{{Kind: TypeChanged, Path: "$.energy.energy", Detail: "expected number, got string"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := spec.Validate(tt.schema, []byte(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("issues = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("issue %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+
+	if _, err := spec.Validate(item, []byte(`<html>`)); err == nil {
+		t.Error("a body that isn't JSON validated")
+	}
+}
+
+func TestResponseSchema(t *testing.T) {
+	spec := parse(t)
+	for _, tt := range []struct {
+		method, path string
+		status       int
+	}{
+		{"GET", "/nope", 200},
+		{"POST", "/items/{id}", 200},
+		{"GET", "/items/{id}", 500},
+		{"GET", "/items/{id}", 404}, // no JSON body
+	} {
+		if _, err := spec.ResponseSchema(tt.method, tt.path, tt.status); err == nil {
+			t.Errorf("ResponseSchema(%s %s %d) succeeded", tt.method, tt.path, tt.status)
+		}
+	}
+	if _, err := Parse([]byte("openapi: 3.0.3\n")); err == nil {
+		t.Error("a document without paths parsed")
+	}
+}
+
+// TestRepoSpec checks that the spec shipped with the CLI still parses and
+// resolves the profile schema doctor checks.
+func TestRepoSpec(t *testing.T) {
+	data, err := os.ReadFile("../../yazio-api.yaml")
+	if err != nil {
+		t.Skip(err)
+	}
+	spec, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	profile, err := spec.ResponseSchema("GET", "/user", 200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := profile["properties"]; !ok {
+		t.Errorf("UserProfile schema = %v, want its $ref resolved", profile)
+	}
+}
@@ -0,0 +1,97 @@
+// Package redact strips credentials and personal data from HTTP traffic before
+// it is written anywhere (cassettes, logs, the debug page).
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// Placeholder replaces every redacted value.
+const Placeholder = "REDACTED"
+
+// secretKeys are JSON keys whose values are always replaced.
+var secretKeys = map[string]bool{
+	"access_token":  true,
+	"refresh_token": true,
+	"password":      true,
+	"client_secret": true,
+	"client_id":     true,
+	"username":      true,
+	"email":         true,
+	"user_token":    true,
+}
+
+// secretHeaders are header names whose values are always replaced.
+var secretHeaders = map[string]bool{
+	"Authorization": true,
+	"Cookie":        true,
+	"Set-Cookie":    true,
+}
+
+var emailRe = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+// Header returns a copy of h with secret header values replaced.
+func Header(h http.Header) http.Header {
+	out := make(http.Header, len(h))
+	for k, v := range h {
+		if secretHeaders[http.CanonicalHeaderKey(k)] {
+			out[k] = []string{Placeholder}
+			continue
+		}
+		out[k] = append([]string(nil), v...)
+	}
+	return out
+}
+
+// JSON returns body with secret keys replaced. Bodies that are not valid JSON
+// are passed through String instead.
+func JSON(body []byte) []byte {
+	if len(body) == 0 {
+		return body
+	}
+	// Decode numbers as json.Number so re-encoding doesn't change them.
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return []byte(String(string(body)))
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(value(v)); err != nil {
+		return []byte(String(string(body)))
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n")
+}
+
+func value(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, val := range v {
+			if secretKeys[strings.ToLower(k)] {
+				v[k] = Placeholder
+			} else {
+				v[k] = value(val)
+			}
+		}
+		return v
+	case []any:
+		for i := range v {
+			v[i] = value(v[i])
+		}
+		return v
+	case string:
+		return String(v)
+	}
+	return v
+}
+
+// String replaces e-mail addresses in s.
+func String(s string) string {
+	return emailRe.ReplaceAllString(s, Placeholder)
+}
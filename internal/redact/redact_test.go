@@ -0,0 +1,119 @@
+package redact
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "token response",
+			in:   `{"access_token":"a.b.c","refresh_token":"r-123","expires_in":172800,"token_type":"bearer"}`,
+			want: `{"access_token":"REDACTED","expires_in":172800,"refresh_token":"REDACTED","token_type":"bearer"}`,
+		},
+		{
+			name: "login request",
+			in:   `{"client_id":"id","client_secret":"s3cret","username":"me@example.com","password":"hunter2","grant_type":"password"}`,
+			want: `{"client_id":"REDACTED","client_secret":"REDACTED","grant_type":"password","password":"REDACTED","username":"REDACTED"}`,
+		},
+		{
+			name: "keys are matched case-insensitively",
+			in:   `{"Password":"x","Access_Token":"y"}`,
+			want: `{"Access_Token":"REDACTED","Password":"REDACTED"}`,
+		},
+		{
+			name: "nested objects and arrays",
+			in:   `{"user":{"email":"me@example.com","sex":"female"},"items":[{"refresh_token":"r"},{"amount":1.5}]}`,
+			want: `{"items":[{"refresh_token":"REDACTED"},{"amount":1.5}],"user":{"email":"REDACTED","sex":"female"}}`,
+		},
+		{
+			name: "e-mail addresses inside strings",
+			in:   `{"message":"no account for jane.doe+yazio@mail.example.org, sorry"}`,
+			want: `{"message":"no account for REDACTED, sorry"}`,
+		},
+		{
+			name: "numbers keep their precision",
+			in:   `{"amount":0.30000000000000004,"id":12345678901234567890}`,
+			want: `{"amount":0.30000000000000004,"id":12345678901234567890}`,
+		},
+		{
+			name: "html is not escaped",
+			in:   `{"name":"Ben & Jerry's <Cookie>"}`,
+			want: `{"name":"Ben & Jerry's <Cookie>"}`,
+		},
+		{
+			name: "not json",
+			in:   `error: user me@example.com not found`,
+			want: `error: user REDACTED not found`,
+		},
+		{
+			name: "empty",
+			in:   ``,
+			want: ``,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(JSON([]byte(tt.in))); got != tt.want {
+				t.Errorf("JSON(%s)\n got %s\nwant %s", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONKeepsStructure(t *testing.T) {
+	in := `{"products":[{"id":"p1","nutrients":{"energy.energy":0.89}}],"simple_products":[]}`
+	var before, after any
+	json.Unmarshal([]byte(in), &before)
+	if err := json.Unmarshal(JSON([]byte(in)), &after); err != nil {
+		t.Fatalf("redacted body is not valid JSON: %v", err)
+	}
+	b1, _ := json.Marshal(before)
+	b2, _ := json.Marshal(after)
+	if string(b1) != string(b2) {
+		t.Errorf("body without secrets changed:\n got %s\nwant %s", b2, b1)
+	}
+}
+
+func TestHeader(t *testing.T) {
+	h := http.Header{}
+	h.Set("Authorization", "Bearer a.b.c")
+	h.Set("Cookie", "session=1")
+	h.Set("Set-Cookie", "session=2")
+	h.Set("Content-Type", "application/json")
+	h["authorization"] = []string{"Bearer lower-case"} // not canonicalized
+
+	got := Header(h)
+	for _, k := range []string{"Authorization", "Cookie", "Set-Cookie", "authorization"} {
+		if v := got[k]; len(v) != 1 || v[0] != Placeholder {
+			t.Errorf("%s = %q, want redacted", k, v)
+		}
+	}
+	if v := got.Get("Content-Type"); v != "application/json" {
+		t.Errorf("Content-Type = %q, want it kept", v)
+	}
+	if h.Get("Authorization") != "Bearer a.b.c" {
+		t.Error("Header modified its argument")
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"me@example.com", "REDACTED"},
+		{"a b.c@d-e.co.uk c", "a REDACTED c"},
+		{"two: x@y.io and z@w.de", "two: REDACTED and REDACTED"},
+		{"no at sign", "no at sign"},
+		{"not an address: foo@bar", "not an address: foo@bar"},
+	}
+	for _, tt := range tests {
+		if got := String(tt.in); got != tt.want {
+			t.Errorf("String(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,101 @@
+// Package search filters and sorts product search results. The search command
+// and the TUI's Search tab share it.
+package search
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/koriwi/yazio-cli/internal/models"
+)
+
+// Sort is the order of the results.
+type Sort string
+
+const (
+	SortRelevance Sort = "relevance" // as returned by the API
+	SortProtein   Sort = "protein"   // most protein per kcal first
+	SortDensity   Sort = "density"   // lowest energy density (kcal per 100 g) first
+)
+
+// Sorts are all orders, in the order the TUI cycles through them.
+var Sorts = []Sort{SortRelevance, SortProtein, SortDensity}
+
+// ParseSort returns the Sort named s.
+func ParseSort(s string) (Sort, error) {
+	for _, o := range Sorts {
+		if string(o) == strings.ToLower(s) {
+			return o, nil
+		}
+	}
+	return "", fmt.Errorf("unknown sort %q, want relevance, protein or density", s)
+}
+
+// Label describes the order for display.
+func (s Sort) Label() string {
+	switch s {
+	case SortProtein:
+		return "protein per kcal"
+	case SortDensity:
+		return "energy density"
+	}
+	return "relevance"
+}
+
+// Filter selects results. Nutrient limits are per 100 g (or ml) and 0 means
+// no limit.
+type Filter struct {
+	Verified   bool    // only products checked by YAZIO
+	MinProtein float64 // g
+	MaxKcal    float64
+}
+
+// Active reports whether the filter excludes anything.
+func (f Filter) Active() bool {
+	return f.Verified || f.MinProtein > 0 || f.MaxKcal > 0
+}
+
+// Match reports whether p passes the filter.
+func (f Filter) Match(p models.ProductResponse) bool {
+	if f.Verified && !p.IsVerified {
+		return false
+	}
+	if f.MinProtein > 0 && p.Nutrients.Protein*100 < f.MinProtein {
+		return false
+	}
+	if f.MaxKcal > 0 && p.Nutrients.EnergyKcal*100 > f.MaxKcal {
+		return false
+	}
+	return true
+}
+
+// ProteinPerKcal returns grams of protein per 100 kcal, 0 without energy.
+func ProteinPerKcal(p models.ProductResponse) float64 {
+	if p.Nutrients.EnergyKcal <= 0 {
+		return 0
+	}
+	return p.Nutrients.Protein / p.Nutrients.EnergyKcal * 100
+}
+
+// Apply returns the products that pass f in the order s. Ties keep the API's
+// order, so the most relevant of equal results comes first.
+func Apply(products []models.ProductResponse, f Filter, s Sort) []models.ProductResponse {
+	out := make([]models.ProductResponse, 0, len(products))
+	for _, p := range products {
+		if f.Match(p) {
+			out = append(out, p)
+		}
+	}
+	switch s {
+	case SortProtein:
+		sort.SliceStable(out, func(i, j int) bool {
+			return ProteinPerKcal(out[i]) > ProteinPerKcal(out[j])
+		})
+	case SortDensity:
+		sort.SliceStable(out, func(i, j int) bool {
+			return out[i].Nutrients.EnergyKcal < out[j].Nutrients.EnergyKcal
+		})
+	}
+	return out
+}
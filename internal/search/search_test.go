@@ -0,0 +1,87 @@
+package search
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/koriwi/yazio-cli/internal/models"
+)
+
+// product returns a product with kcal and protein given per 100 g.
+func product(name string, kcal, protein float64, verified bool) models.ProductResponse {
+	return models.ProductResponse{
+		ID:         name,
+		Name:       name,
+		IsVerified: verified,
+		Nutrients:  models.ProductNutrients{EnergyKcal: kcal / 100, Protein: protein / 100},
+	}
+}
+
+func TestApply(t *testing.T) {
+	// In the API's order
+	products := []models.ProductResponse{
+		product("apple", 50, 0.25, false),
+		product("chicken", 150, 25, true),
+		product("skyr", 62.5, 12.5, true),
+		product("almonds", 575, 25, true),
+		product("water", 0, 0, true),
+		product("tofu", 125, 12.5, false),
+	}
+	tests := []struct {
+		name   string
+		filter Filter
+		sort   Sort
+		want   string
+	}{
+		{"everything", Filter{}, SortRelevance, "apple chicken skyr almonds water tofu"},
+		{"verified", Filter{Verified: true}, SortRelevance, "chicken skyr almonds water"},
+		{"min protein is inclusive", Filter{MinProtein: 12.5}, SortRelevance, "chicken skyr almonds tofu"},
+		{"max kcal is inclusive", Filter{MaxKcal: 150}, SortRelevance, "apple chicken skyr water tofu"},
+		{"all filters", Filter{Verified: true, MinProtein: 12.5, MaxKcal: 150}, SortRelevance, "chicken skyr"},
+		{"protein per kcal", Filter{}, SortProtein, "skyr chicken tofu almonds apple water"},
+		{"energy density", Filter{}, SortDensity, "water apple skyr tofu chicken almonds"},
+		{"filtered and sorted", Filter{MinProtein: 12.5}, SortDensity, "skyr tofu chicken almonds"},
+		{"nothing passes", Filter{MinProtein: 50}, SortProtein, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, p := range Apply(products, tt.filter, tt.sort) {
+				got = append(got, p.Name)
+			}
+			if s := strings.Join(got, " "); s != tt.want {
+				t.Errorf("got %q, want %q", s, tt.want)
+			}
+		})
+	}
+	if products[0].Name != "apple" || products[4].Name != "water" {
+		t.Error("Apply reordered its argument")
+	}
+}
+
+func TestApplyKeepsTies(t *testing.T) {
+	products := []models.ProductResponse{
+		product("first", 100, 10, false),
+		product("second", 100, 10, false),
+		product("third", 100, 10, false),
+	}
+	for _, s := range Sorts {
+		got := Apply(products, Filter{}, s)
+		if got[0].Name != "first" || got[1].Name != "second" || got[2].Name != "third" {
+			t.Errorf("%s: equal results were reordered", s)
+		}
+	}
+}
+
+func TestParseSort(t *testing.T) {
+	for in, want := range map[string]Sort{"relevance": SortRelevance, "Protein": SortProtein, "DENSITY": SortDensity} {
+		if got, err := ParseSort(in); err != nil || got != want {
+			t.Errorf("ParseSort(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "kcal", "protein "} {
+		if got, err := ParseSort(in); err == nil {
+			t.Errorf("ParseSort(%q) = %q, want an error", in, got)
+		}
+	}
+}
@@ -1,35 +1,84 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"log/slog"
 	"os"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/koriwi/yazio-cli/internal/api"
 	"github.com/koriwi/yazio-cli/internal/auth"
+	"github.com/koriwi/yazio-cli/internal/cassette"
 	"github.com/koriwi/yazio-cli/tui"
 )
 
 func main() {
+	os.Exit(run())
+}
+
+// run is main without os.Exit, so deferred cleanup (e.g. saving a recording)
+// happens on every exit path.
+func run() int {
+	flag.Usage = func() {
+		printUsage()
+		flag.PrintDefaults()
+	}
 	refresh := flag.Bool("refresh", false, "exchange the stored refresh token for a new access token")
+	record := flag.String("record", "", "record all API traffic (redacted) to this cassette file")
+	logFile := flag.String("log-file", "", "append a structured log of every API request to this file")
+	logLevel := flag.String("log-level", "info", "log level: debug (includes redacted bodies), info, warn, error")
+	// Global flags come before the command, e.g. --log-file x diary
 	flag.Parse()
 
+	if *logFile != "" {
+		closeLog, err := setupLogging(*logFile, *logLevel)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "log: %v\n", err)
+			return 2
+		}
+		defer closeLog()
+	}
+
+	if *record != "" {
+		rec := cassette.NewRecorder(nil)
+		api.DefaultTransport = rec
+		defer func() {
+			if err := rec.Save(*record); err != nil {
+				fmt.Fprintf(os.Stderr, "failed to save cassette: %v\n", err)
+			}
+		}()
+	}
+
+	if flag.NArg() > 0 {
+		if c := findCommand(flag.Arg(0)); c != nil {
+			return c.run(flag.Args()[1:])
+		}
+		if flag.Arg(0) == "help" {
+			flag.Usage()
+			return 0
+		}
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", flag.Arg(0))
+		flag.Usage()
+		return 2
+	}
+
 	if *refresh {
 		cfg, err := auth.LoadConfig()
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "error loading config: %v\n", err)
-			os.Exit(1)
+			return 1
 		}
 		if cfg.RefreshToken == "" {
 			fmt.Fprintf(os.Stderr, "no refresh token stored — log in through the app first\n")
-			os.Exit(1)
+			return 1
 		}
 		c := api.New("")
 		resp, err := c.RefreshAccessToken(cfg.RefreshToken)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "refresh failed: %v\n", err)
-			os.Exit(1)
+			return 1
 		}
 		// Prefer the new refresh token; fall back to the old one if the server didn't rotate it.
 		newRefresh := resp.RefreshToken
@@ -38,10 +87,10 @@ func main() {
 		}
 		if err := auth.SaveToken(cfg.Email, resp.AccessToken, newRefresh); err != nil {
 			fmt.Fprintf(os.Stderr, "failed to save token: %v\n", err)
-			os.Exit(1)
+			return 1
 		}
 		fmt.Println("token refreshed")
-		return
+		return 0
 	}
 
 	cfg, _ := auth.LoadConfig()
@@ -53,6 +102,42 @@ func main() {
 
 	if _, err := p.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
-		os.Exit(1)
+		return 1
+	}
+	return 0
+}
+
+// setupLogging sends API request logs to path as JSON lines.
+func setupLogging(path, level string) (func(), error) {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("invalid --log-level %q", level)
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+	api.DefaultLogger = slog.New(slog.NewJSONHandler(f, &slog.HandlerOptions{Level: lvl}))
+	return func() { f.Close() }, nil
+}
+
+// errNotLoggedIn is returned by loadClient when no token is stored.
+var errNotLoggedIn = errors.New("not logged in — log in through the app first")
+
+// loadClient returns a client for the stored session that persists rotated
+// tokens, like the TUI does.
+func loadClient() (*api.Client, error) {
+	cfg, err := auth.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("error loading config: %w", err)
+	}
+	if cfg.Token == "" {
+		return nil, errNotLoggedIn
 	}
+	client := api.New(cfg.Token)
+	email := cfg.Email
+	client.SetRefresh(cfg.RefreshToken, func(accessToken, refreshToken string) {
+		auth.SaveToken(email, accessToken, refreshToken)
+	})
+	return client, nil
 }
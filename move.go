@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/koriwi/yazio-cli/internal/diary"
+	"github.com/koriwi/yazio-cli/internal/models"
+)
+
+const moveUsage = "usage: yazio-cli move <entry-id>... --meal M [--date D] [--dry-run] [--output table|json|tsv]"
+
+// runMove moves entries to another meal of their day. They get new ids, as
+// the entries are logged again in the new meal before the old ones are
+// deleted.
+func runMove(args []string) int {
+	fs := flag.NewFlagSet("move", flag.ContinueOnError)
+	meal := fs.String("meal", "", "meal to move to: "+strings.Join(models.MealTimes, ", ")+" (required)")
+	dateStr := fs.String("date", "", "day of the entries (default today)")
+	dryRun := fs.Bool("dry-run", false, "show the moved entries without changing anything")
+	output := outputFlag(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() == 0 || *meal == "" {
+		fmt.Fprintln(os.Stderr, moveUsage)
+		return exitUsage
+	}
+	if !slices.Contains(models.MealTimes, *meal) {
+		fmt.Fprintf(os.Stderr, "invalid --meal %q, want one of %s\n", *meal, strings.Join(models.MealTimes, ", "))
+		return exitUsage
+	}
+	date, err := diary.ParseDate(*dateStr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+
+	client, err := loadClient()
+	if err != nil {
+		return fail(err)
+	}
+	entries, err := sourceEntries(client, date, fs.Args(), "")
+	if err != nil {
+		return fail(err)
+	}
+	var moving []models.DiaryEntry
+	for _, e := range entries {
+		if e.MealTime != *meal {
+			moving = append(moving, e)
+		}
+	}
+	if len(moving) == 0 {
+		fmt.Fprintf(os.Stderr, "already in %s\n", models.MealTimeLabel(*meal))
+		return exitOK
+	}
+
+	reqs := diary.CopyRequests(moving, []time.Time{date}, *meal)
+	if !*dryRun {
+		if err := diary.Replace(client, moving, reqs); err != nil {
+			return fail(err)
+		}
+	}
+	printCopies(moving, reqs, *dryRun, *output, "moved")
+	return exitOK
+}
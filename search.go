@@ -0,0 +1,145 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/koriwi/yazio-cli/internal/models"
+	"github.com/koriwi/yazio-cli/internal/search"
+)
+
+// runSearch searches the food database of the user's country and prints the
+// results with their producer and nutrients per 100 g, optionally filtered
+// and re-sorted.
+func runSearch(args []string) int {
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	limit := fs.Int("limit", 20, "maximum number of results")
+	country := fs.String("country", "", "food database country, e.g. DE (default from your profile)")
+	language := fs.String("language", "", "result language, e.g. en (default from your profile)")
+	verified := fs.Bool("verified", false, "only products verified by YAZIO")
+	minProtein := fs.Float64("min-protein", 0, "minimum protein in g per 100 g")
+	maxKcal := fs.Float64("max-kcal", 0, "maximum kcal per 100 g")
+	sortStr := fs.String("sort", "relevance", "order: relevance, protein (protein per kcal) or density (kcal per 100 g)")
+	output := outputFlag(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return exitUsage
+	}
+	query := strings.TrimSpace(strings.Join(fs.Args(), " "))
+	if query == "" || *limit < 1 {
+		fmt.Fprintln(os.Stderr, "usage: yazio-cli search [--limit N] [--verified] [--min-protein G] [--max-kcal N] [--sort relevance|protein|density] [--country CC] [--language xx] [--output table|json|tsv] <query>")
+		return exitUsage
+	}
+	order, err := search.ParseSort(*sortStr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	filter := search.Filter{Verified: *verified, MinProtein: *minProtein, MaxKcal: *maxKcal}
+
+	client, err := loadClient()
+	if err != nil {
+		return fail(err)
+	}
+	profile, err := client.GetProfile()
+	if err != nil {
+		return fail(fmt.Errorf("profile: %w", err))
+	}
+	req := models.NewSearchRequest(query, *profile)
+	if *country != "" {
+		req.Country = strings.ToUpper(*country)
+	}
+	if *language != "" {
+		req.Language = strings.ToLower(*language)
+	}
+	req.Limit = *limit
+	if filter.Active() || order != search.SortRelevance {
+		// Filter and sort a larger page, so the results aren't just the
+		// first page reordered
+		req.Limit = max(*limit, searchCandidates)
+	}
+
+	products, err := client.SearchProducts(req)
+	if err != nil {
+		return fail(err)
+	}
+	products = search.Apply(products, filter, order)
+	products = products[:min(len(products), *limit)]
+
+	switch *output {
+	case outputJSON:
+		results := make([]searchResult, 0, len(products))
+		for _, p := range products {
+			results = append(results, newSearchResult(p))
+		}
+		writeJSON(results)
+	case outputTSV:
+		t := newTSV("id", "name", "producer", "is_verified", "base_unit", "energy_kcal", "protein", "carb", "fat")
+		for _, p := range products {
+			r := newSearchResult(p)
+			t.row(r.ID, r.Name, r.Producer, strconv.FormatBool(r.IsVerified), r.BaseUnit,
+				num(r.EnergyKcal), num(r.Protein), num(r.Carb), num(r.Fat))
+		}
+	default:
+		if len(products) == 0 {
+			fmt.Fprintf(os.Stderr, "no results for %q\n", query)
+			return exitOK
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tPRODUCER\tPER 100 G\t\t\t\tP/100 KCAL")
+		for _, p := range products {
+			n := p.Nutrients
+			name := p.Name
+			if p.IsVerified {
+				name += " ✓"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%.1fg\n", p.ID, name, p.Producer,
+				nutrientCells(n.EnergyKcal*100, n.Protein*100, n.Carb*100, n.Fat*100), search.ProteinPerKcal(p))
+		}
+		w.Flush()
+	}
+	return exitOK
+}
+
+// searchCandidates is how many results are requested when filtering or
+// sorting.
+const searchCandidates = 100
+
+// searchResult is the JSON schema of one `search --output json` result.
+// Nutrients are per 100 g (or ml) of the base unit.
+type searchResult struct {
+	ID         string           `json:"id"`
+	Name       string           `json:"name"`
+	Producer   string           `json:"producer"`
+	IsVerified bool             `json:"is_verified"`
+	BaseUnit   string           `json:"base_unit"`
+	EnergyKcal float64          `json:"energy_kcal"`
+	Protein    float64          `json:"protein"`
+	Carb       float64          `json:"carb"`
+	Fat        float64          `json:"fat"`
+	Servings   []models.Serving `json:"servings"`
+}
+
+func newSearchResult(p models.ProductResponse) searchResult {
+	servings := p.Servings
+	if servings == nil {
+		servings = []models.Serving{}
+	}
+	n := p.Nutrients
+	return searchResult{
+		ID:         p.ID,
+		Name:       p.Name,
+		Producer:   p.Producer,
+		IsVerified: p.IsVerified,
+		BaseUnit:   p.BaseUnit,
+		EnergyKcal: math.Round(n.EnergyKcal*100*10) / 10,
+		Protein:    math.Round(n.Protein*100*10) / 10,
+		Carb:       math.Round(n.Carb*100*10) / 10,
+		Fat:        math.Round(n.Fat*100*10) / 10,
+		Servings:   servings,
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/koriwi/yazio-cli/internal/diary"
+	"github.com/koriwi/yazio-cli/internal/models"
+)
+
+// runTotals prints the daily calorie and macro totals of a date range, one
+// row per day including days without entries.
+func runTotals(args []string) int {
+	fs := flag.NewFlagSet("totals", flag.ContinueOnError)
+	fromStr := fs.String("from", "", "first day (default 6 days before --to)")
+	toStr := fs.String("to", "", "last day (default today)")
+	output := outputFlag(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return exitUsage
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintln(os.Stderr, "usage: yazio-cli totals [--from YYYY-MM-DD] [--to YYYY-MM-DD] [--output table|json|tsv]")
+		return exitUsage
+	}
+	to, err := diary.ParseDate(*toStr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	from := to.AddDate(0, 0, -6)
+	if *fromStr != "" {
+		if from, err = diary.ParseDate(*fromStr); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitUsage
+		}
+	}
+	if from.After(to) {
+		fmt.Fprintln(os.Stderr, "--from is after --to")
+		return exitUsage
+	}
+
+	client, err := loadClient()
+	if err != nil {
+		return fail(err)
+	}
+	logged, err := client.GetDailyNutrientsRange(from, to)
+	if err != nil {
+		return fail(err)
+	}
+	byDate := map[string]models.DailyNutrient{}
+	for _, d := range logged {
+		byDate[d.Date] = d
+	}
+	var days []models.DailyNutrient
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		key := d.Format(time.DateOnly)
+		day := byDate[key]
+		day.Date = key
+		days = append(days, day)
+	}
+
+	switch *output {
+	case outputJSON:
+		writeJSON(days)
+	case outputTSV:
+		t := newTSV("date", "energy", "energy_goal", "protein", "carb", "fat")
+		for _, d := range days {
+			t.row(d.Date, num(d.Energy), num(d.EnergyGoal), num(d.Protein), num(d.Carb), num(d.Fat))
+		}
+	default:
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "DATE\tKCAL\tGOAL\tPROTEIN\tCARBS\tFAT")
+		for _, d := range days {
+			fmt.Fprintf(w, "%s\t%.0f\t%.0f\t%.0fg\t%.0fg\t%.0fg\n", d.Date, d.Energy, d.EnergyGoal, d.Protein, d.Carb, d.Fat)
+		}
+		w.Flush()
+	}
+	return exitOK
+}
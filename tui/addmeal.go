@@ -3,6 +3,7 @@ package tui
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -10,8 +11,11 @@ import (
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/koriwi/yazio-cli/internal/api"
+	"github.com/koriwi/yazio-cli/internal/diary"
 	"github.com/koriwi/yazio-cli/internal/models"
+	"github.com/koriwi/yazio-cli/internal/search"
 )
 
 type addMealTab int
@@ -36,12 +40,16 @@ type addMealModel struct {
 	client  *api.Client
 	cache   *sync.Map
 	date    time.Time
-	profile *models.UserProfile // for search params (country, sex)
+	profile *models.UserProfile // for search params (country, sex); nil until loaded
 	width   int
 	height  int
 
 	// Browse state
-	recent          []models.ProductResponse
+	recent          []recentItem // ranked for recentMeal
+	recentLogs      []recentLog
+	recentDetails   map[recentKey]*models.ProductResponse
+	recentMeal      int // 0 = all meals, otherwise models.MealTimes[recentMeal-1]
+	recentDays      int
 	results         []models.ProductResponse
 	listIdx         int
 	loading         bool
@@ -49,65 +57,108 @@ type addMealModel struct {
 	err             string
 
 	// Search state
-	searchInput textinput.Model
+	searchInput   textinput.Model
+	countryInput  textinput.Model // per-search override of the food database country
+	languageInput textinput.Model // per-search override of the result language
+	minProtein    textinput.Model // filter: g protein per 100 g
+	maxKcal       textinput.Model // filter: kcal per 100 g
+	verifiedOnly  bool
+	resultSort    search.Sort
+	lastSearch    models.SearchRequest // the search whose results are shown or on their way
+	searchHasMore bool                 // last page was full, more results may follow
+	loadingMore   bool
 
 	// Selected product + entry details
 	selected       *models.ProductResponse
+	selectedRecipe bool // selected is a recipe; amounts are portions
 	amountInput    textinput.Model
-	servingIdx     int  // index into productServings(selected)
+	servingIdx     int  // index into models.ProductServings(selected)
 	servingFocused bool // true when serving selector has focus
 	mealTimeIdx    int  // index into models.MealTimes
 
 	// Edit mode (non-empty = editing an existing consumed item)
 	editConsumedID string
-	editServing    string  // original serving name, for pre-selecting serving index
-	editServingQty float64 // original quantity, for pre-filling amount input
-}
-
-type recentLoadedMsg struct {
-	products []models.ProductResponse
-	err      string
+	editEntry      models.DiaryEntry // the entry as it was, so the edit can be undone
+	editServing    string            // original serving name, for pre-selecting serving index
+	editServingQty float64           // original quantity, for pre-filling amount input
 }
 
 type searchResultsMsg struct {
+	req      models.SearchRequest
 	products []models.ProductResponse
 	err      string
 }
 
+// searchPageSize is the number of search results requested per page.
+const searchPageSize = 30
+
 type productFetchedMsg struct {
 	product *models.ProductResponse
 	err     string
 }
 
-type addSuccessMsg struct{}
+// addSuccessMsg reports the entry that was logged.
+type addSuccessMsg struct{ entry models.DiaryEntry }
 type addErrMsg struct{ err string }
 
 func newAddMealModel(client *api.Client, cache *sync.Map, date time.Time, profile *models.UserProfile) addMealModel {
-	search := textinput.New()
-	search.Placeholder = "Search foods..."
-	search.CharLimit = 128
-	search.Width = 40
+	query := textinput.New()
+	query.Placeholder = "Search foods..."
+	query.CharLimit = 128
+	query.Width = 40
+
+	country := textinput.New()
+	country.Placeholder = "DE"
+	country.CharLimit = 2
+	country.Width = 4
+	country.Prompt = ""
+
+	language := textinput.New()
+	language.Placeholder = "en"
+	language.CharLimit = 5
+	language.Width = 6
+	language.Prompt = ""
+
+	minProtein := textinput.New()
+	minProtein.Placeholder = "any"
+	minProtein.CharLimit = 5
+	minProtein.Width = 5
+	minProtein.Prompt = ""
+
+	maxKcal := textinput.New()
+	maxKcal.Placeholder = "any"
+	maxKcal.CharLimit = 5
+	maxKcal.Width = 5
+	maxKcal.Prompt = ""
 
 	amount := textinput.New()
 	amount.Placeholder = "100"
 	amount.CharLimit = 10
 	amount.Width = 15
 
-	return addMealModel{
-		tab:         tabRecent,
-		client:      client,
-		cache:       cache,
-		date:        date,
-		profile:     profile,
-		searchInput: search,
-		amountInput: amount,
-		mealTimeIdx: 0,
+	m := addMealModel{
+		tab:           tabRecent,
+		client:        client,
+		cache:         cache,
+		date:          date,
+		searchInput:   query,
+		countryInput:  country,
+		languageInput: language,
+		minProtein:    minProtein,
+		maxKcal:       maxKcal,
+		resultSort:    search.SortRelevance,
+		amountInput:   amount,
+		mealTimeIdx:   0,
+		recentDays:    recentDays(),
 	}
+	m.setProfile(profile)
+	return m
 }
 
 func newEditMealModel(client *api.Client, cache *sync.Map, date time.Time, profile *models.UserProfile, entry models.DiaryEntry) addMealModel {
 	m := newAddMealModel(client, cache, date, profile)
 	m.editConsumedID = entry.ConsumedID
+	m.editEntry = entry
 	m.editServing = entry.Serving
 	qty := entry.ServingQuantity
 	if qty <= 0 {
@@ -124,105 +175,158 @@ func newEditMealModel(client *api.Client, cache *sync.Map, date time.Time, profi
 	return m
 }
 
-// productServings returns the servings the product defines, always including gram.
-func productServings(p *models.ProductResponse) []models.Serving {
-	if p == nil || len(p.Servings) == 0 {
-		return []models.Serving{{Amount: 1, Serving: "gram"}}
-	}
-	for _, s := range p.Servings {
-		if s.Serving == "gram" {
-			return p.Servings
+func (m addMealModel) loadRecent() tea.Cmd {
+	client := m.client
+	cache := m.cache
+	date := m.date
+	days := m.recentDays
+
+	return func() tea.Msg {
+		logs, err := fetchRecentLogs(client, date, days)
+		if err == nil {
+			var details map[recentKey]*models.ProductResponse
+			details, err = fetchRecentDetails(recentCandidates(logs, date), client, cache)
+			if err == nil {
+				return recentLoadedMsg{logs: logs, details: details}
+			}
+		}
+		if errors.Is(err, api.ErrSessionExpired) {
+			return sessionExpiredMsg{}
 		}
+		return recentLoadedMsg{err: err.Error()}
 	}
-	return append(p.Servings, models.Serving{Amount: 1, Serving: "gram"})
 }
 
-// defaultAmountForServing returns the default quantity string for a serving.
-func defaultAmountForServing(s models.Serving) string {
-	if s.Serving == "gram" {
-		return "100"
+// recentMealTime returns the meal time the Recent tab is filtered by, or ""
+// for all meals.
+func (m addMealModel) recentMealTime() string {
+	if m.recentMeal == 0 {
+		return ""
 	}
-	return "1"
+	return models.MealTimes[m.recentMeal-1]
 }
 
-// servingDisplayName returns a short user-facing label for a serving.
-func servingDisplayName(s models.Serving) string {
-	if s.Serving == "gram" {
-		return "g"
+// servings returns the servings offered for the selected item.
+func (m addMealModel) servings() []models.Serving {
+	if m.selectedRecipe {
+		return []models.Serving{{Amount: 1, Serving: "portion"}}
 	}
-	return s.Serving
+	return models.ProductServings(m.selected)
 }
 
-// amountGramsForServing converts user-entered quantity to total grams.
-func amountGramsForServing(qty float64, s models.Serving) float64 {
-	return qty * s.Amount // gram.Amount == 1, so gram-based is a no-op
+// setProfile stores the loaded profile and pre-fills the overrides with its
+// locale, so the user can see which database is searched and change it
+// before pressing Enter.
+func (m *addMealModel) setProfile(profile *models.UserProfile) {
+	m.profile = profile
+	if profile == nil {
+		return
+	}
+	defaults := models.NewSearchRequest("", *profile)
+	if m.countryInput.Value() == "" {
+		m.countryInput.SetValue(defaults.Country)
+	}
+	if m.languageInput.Value() == "" {
+		m.languageInput.SetValue(defaults.Language)
+	}
 }
 
-func (m addMealModel) loadRecent() tea.Cmd {
-	client := m.client
-	cache := m.cache
-	date := m.date
-
-	return func() tea.Msg {
-		seen := map[string]bool{}
-		var products []models.ProductResponse
-
-		// Look at the last 7 days of consumed items
-		for i := 0; i < 7; i++ {
-			d := date.AddDate(0, 0, -i)
-			consumed, err := client.GetConsumedItems(d)
-			if err != nil {
-				continue
-			}
-			for _, cp := range consumed.Products {
-				if seen[cp.ProductID] {
-					continue
-				}
-				seen[cp.ProductID] = true
-				product := fetchProductCached(cp.ProductID, client, cache)
-				if product != nil {
-					products = append(products, *product)
-				}
-			}
-			if len(products) >= 20 {
-				break
-			}
-		}
-		return recentLoadedMsg{products: products}
+// newSearch builds the first-page request for query from the profile and the
+// country/language overrides in the Search tab. The profile must be loaded.
+func (m addMealModel) newSearch(query string) models.SearchRequest {
+	req := models.NewSearchRequest(query, *m.profile)
+	if v := strings.TrimSpace(m.countryInput.Value()); v != "" {
+		req.Country = strings.ToUpper(v)
+	}
+	if v := strings.TrimSpace(m.languageInput.Value()); v != "" {
+		req.Language = strings.ToLower(v)
 	}
+	req.Limit = searchPageSize
+	return req
 }
 
-func (m addMealModel) doSearch(query string) tea.Cmd {
+func (m addMealModel) doSearch(req models.SearchRequest) tea.Cmd {
 	client := m.client
-	country, sex := "DE", "male"
-	if m.profile != nil {
-		if m.profile.Country != "" {
-			country = m.profile.Country
-		}
-		if m.profile.Sex != "" {
-			sex = m.profile.Sex
-		}
-	}
 
 	return func() tea.Msg {
-		products, err := client.SearchProducts(query, country, sex)
+		products, err := client.SearchProducts(req)
 		if err != nil {
 			if errors.Is(err, api.ErrSessionExpired) {
 				return sessionExpiredMsg{}
 			}
-			return searchResultsMsg{err: err.Error()}
+			return searchResultsMsg{req: req, err: err.Error()}
 		}
-		return searchResultsMsg{products: products}
+		return searchResultsMsg{req: req, products: products}
+	}
+}
+
+// loadMoreResults requests the page following the current search results.
+func (m addMealModel) loadMoreResults() tea.Cmd {
+	req := m.lastSearch
+	req.Offset = len(m.results)
+	return m.doSearch(req)
+}
+
+// searchFieldCount is the number of Search tab inputs cycled with ctrl+o.
+const searchFieldCount = 5
+
+// focusSearchField focuses one of the Search tab inputs (0 = query,
+// 1 = country, 2 = language, 3 = min protein, 4 = max kcal) and blurs the
+// others.
+func (m *addMealModel) focusSearchField(i int) {
+	inputs := []*textinput.Model{&m.searchInput, &m.countryInput, &m.languageInput, &m.minProtein, &m.maxKcal}
+	for j, in := range inputs {
+		if j == i {
+			in.Focus()
+		} else {
+			in.Blur()
+		}
+	}
+}
+
+// searchField returns the index of the focused Search tab input, or -1 when
+// the result list has focus.
+func (m addMealModel) searchField() int {
+	switch {
+	case m.searchInput.Focused():
+		return 0
+	case m.countryInput.Focused():
+		return 1
+	case m.languageInput.Focused():
+		return 2
+	case m.minProtein.Focused():
+		return 3
+	case m.maxKcal.Focused():
+		return 4
 	}
+	return -1
+}
+
+// searchFilter returns the filter set in the Search tab. Limits that aren't
+// numbers are ignored.
+func (m addMealModel) searchFilter() search.Filter {
+	f := search.Filter{Verified: m.verifiedOnly}
+	f.MinProtein, _ = strconv.ParseFloat(strings.TrimSpace(m.minProtein.Value()), 64)
+	f.MaxKcal, _ = strconv.ParseFloat(strings.TrimSpace(m.maxKcal.Value()), 64)
+	return f
+}
+
+// visibleResults returns the search results that pass the filter, in the
+// selected order.
+func (m addMealModel) visibleResults() []models.ProductResponse {
+	return search.Apply(m.results, m.searchFilter(), m.resultSort)
 }
 
 func (m addMealModel) doFetchProduct(productID string) tea.Cmd {
 	client := m.client
 	cache := m.cache
 	return func() tea.Msg {
-		product := fetchProductCached(productID, client, cache)
-		if product == nil {
-			return productFetchedMsg{err: "failed to load product"}
+		product, err := diary.FetchProduct(productID, client, cache)
+		if err != nil {
+			if errors.Is(err, api.ErrSessionExpired) {
+				return sessionExpiredMsg{}
+			}
+			return productFetchedMsg{err: "failed to load product: " + err.Error()}
 		}
 		return productFetchedMsg{product: product}
 	}
@@ -235,48 +339,54 @@ func (m addMealModel) doAddMeal() tea.Cmd {
 		qty = 1
 	}
 
-	servings := productServings(product)
+	servings := m.servings()
 	s := servings[m.servingIdx]
-	amountGrams := amountGramsForServing(qty, s)
+	amountGrams := models.ServingGrams(qty, s)
+	typ := "product"
+	if m.selectedRecipe {
+		typ = "recipe_portion"
+	}
 
 	mealTime := models.MealTimes[m.mealTimeIdx]
 	client := m.client
 	date := m.date
 	editConsumedID := m.editConsumedID
+	edited := m.editEntry
 
 	return func() tea.Msg {
-		if editConsumedID != "" {
-			if err := client.DeleteConsumedItem(editConsumedID); err != nil {
-				if errors.Is(err, api.ErrSessionExpired) {
-					return sessionExpiredMsg{}
-				}
-				return addErrMsg{err: "delete failed: " + err.Error()}
-			}
-		}
-		err := client.AddConsumedItem(models.AddConsumedRequest{
+		req := models.AddConsumedRequest{
+			ID:              api.NewUUID(),
 			ProductID:       product.ID,
 			Date:            date.Format(time.DateOnly),
 			Daytime:         mealTime,
 			Amount:          amountGrams,
 			Serving:         s.Serving,
 			ServingQuantity: qty,
-			Type:            "product",
-		})
+			Type:            typ,
+		}
+		// An edit logs the new entry before deleting the old one, like a
+		// move, so a failure never loses it
+		var err error
+		if editConsumedID != "" {
+			err = diary.Replace(client, []models.DiaryEntry{edited}, []models.AddConsumedRequest{req})
+		} else {
+			err = client.AddConsumedItem(req)
+		}
 		if err != nil {
 			if errors.Is(err, api.ErrSessionExpired) {
 				return sessionExpiredMsg{}
 			}
 			return addErrMsg{err: err.Error()}
 		}
-		return addSuccessMsg{}
+		return addSuccessMsg{entry: diary.NewEntry(product, req)}
 	}
 }
 
-func (m addMealModel) currentList() []models.ProductResponse {
+func (m addMealModel) listLen() int {
 	if m.tab == tabSearch {
-		return m.results
+		return len(m.visibleResults())
 	}
-	return m.recent
+	return len(m.recent)
 }
 
 func (m addMealModel) Update(msg tea.Msg) (addMealModel, tea.Cmd) {
@@ -291,17 +401,43 @@ func (m addMealModel) Update(msg tea.Msg) (addMealModel, tea.Cmd) {
 		if msg.err != "" {
 			m.err = msg.err
 		} else {
-			m.recent = msg.products
+			m.recentLogs = msg.logs
+			m.recentDetails = msg.details
+			m.recent = recentItems(m.recentLogs, m.recentDetails, m.recentMealTime(), m.date)
 		}
 
 	case searchResultsMsg:
+		// Drop pages of a search that has since been replaced by another
+		if r := m.lastSearch; msg.req.Query != r.Query || msg.req.Country != r.Country || msg.req.Language != r.Language {
+			break
+		}
 		m.loading = false
+		m.loadingMore = false
 		if msg.err != "" {
 			m.err = msg.err
-		} else {
+			break
+		}
+		if msg.req.Offset == 0 {
 			m.results = msg.products
 			m.listIdx = 0
+			m.searchHasMore = len(msg.products) >= msg.req.Limit
+			break
 		}
+		// Append the next page, skipping anything we already have in case the
+		// API ignores the offset and returns the first page again.
+		seen := map[string]bool{}
+		for _, p := range m.results {
+			seen[p.ID] = true
+		}
+		added := 0
+		for _, p := range msg.products {
+			if !seen[p.ID] {
+				seen[p.ID] = true
+				m.results = append(m.results, p)
+				added++
+			}
+		}
+		m.searchHasMore = added > 0 && len(msg.products) >= msg.req.Limit
 
 	case tea.KeyMsg:
 		switch m.step {
@@ -318,58 +454,97 @@ func (m addMealModel) Update(msg tea.Msg) (addMealModel, tea.Cmd) {
 					m.loading = true
 					cmds = append(cmds, m.loadRecent())
 				} else if m.tab == tabSearch {
-					m.searchInput.Focus()
+					m.focusSearchField(0)
+				}
+
+			case "m":
+				// Cycle the Recent tab through all meals and each meal time
+				if m.tab == tabRecent && !m.loading {
+					m.recentMeal = (m.recentMeal + 1) % (len(models.MealTimes) + 1)
+					m.recent = recentItems(m.recentLogs, m.recentDetails, m.recentMealTime(), m.date)
+					m.listIdx = 0
+				}
+
+			case "ctrl+o":
+				// Cycle query → country → language → filters
+				if m.tab == tabSearch {
+					m.focusSearchField((m.searchField() + 1) % searchFieldCount)
+				}
+
+			case "ctrl+v":
+				if m.tab == tabSearch {
+					m.verifiedOnly = !m.verifiedOnly
+					m.listIdx = 0
+				}
+
+			case "ctrl+s":
+				if m.tab == tabSearch {
+					i := slices.Index(search.Sorts, m.resultSort)
+					m.resultSort = search.Sorts[(i+1)%len(search.Sorts)]
+					m.listIdx = 0
 				}
 
 			case "j", "down":
-				list := m.currentList()
-				if m.tab == tabSearch && m.searchInput.Focused() {
-					if len(list) > 0 {
-						m.searchInput.Blur()
+				n := m.listLen()
+				if m.tab == tabSearch && m.searchField() >= 0 {
+					if n > 0 {
+						m.focusSearchField(-1)
 					}
-				} else if m.listIdx < len(list)-1 {
+				} else if m.listIdx < n-1 {
 					m.listIdx++
+				} else if m.tab == tabSearch && m.searchHasMore && !m.loadingMore && !m.loading {
+					m.loadingMore = true
+					m.err = ""
+					cmds = append(cmds, m.loadMoreResults())
 				}
 
 			case "k", "up":
 				if m.listIdx > 0 {
 					m.listIdx--
 				} else if m.tab == tabSearch {
-					m.searchInput.Focus()
+					m.focusSearchField(0)
 				}
 
 			case "enter":
-				if m.tab == tabSearch && m.searchInput.Focused() {
+				if m.tab == tabSearch && m.searchField() >= 0 {
 					q := m.searchInput.Value()
-					if q != "" {
+					if q != "" && m.profile == nil {
+						// The search needs the profile's sex and locale
+						m.err = "your profile isn't loaded yet, try again in a moment"
+					} else if q != "" {
 						m.loading = true
 						m.err = ""
-						cmds = append(cmds, m.doSearch(q))
+						m.focusSearchField(0)
+						m.lastSearch = m.newSearch(q)
+						cmds = append(cmds, m.doSearch(m.lastSearch))
 					}
 					break
 				}
-				list := m.currentList()
-				if m.listIdx < len(list) {
-					p := list[m.listIdx]
+				if m.listIdx < m.listLen() {
 					if m.tab == tabSearch {
 						// Search results only have partial data; fetch full product for servings
 						m.fetchingProduct = true
 						m.err = ""
-						cmds = append(cmds, m.doFetchProduct(p.ID))
+						cmds = append(cmds, m.doFetchProduct(m.visibleResults()[m.listIdx].ID))
 					} else {
 						// Recent items are already fully loaded
-						m.selected = &p
+						it := m.recent[m.listIdx]
+						m.selected = &it.product
+						m.selectedRecipe = it.key.recipe
 						m.step = stepAmount
 						m.servingIdx = 0
 						m.err = ""
-						servings := productServings(&p)
+						if m.recentMeal > 0 {
+							m.mealTimeIdx = m.recentMeal - 1
+						}
+						servings := m.servings()
 						if len(servings) > 1 {
 							m.servingFocused = true
 							m.amountInput.Blur()
-							m.amountInput.SetValue(defaultAmountForServing(servings[0]))
+							m.amountInput.SetValue(models.DefaultServingAmount(servings[0]))
 						} else {
 							m.servingFocused = false
-							m.amountInput.SetValue(defaultAmountForServing(servings[0]))
+							m.amountInput.SetValue(models.DefaultServingAmount(servings[0]))
 							m.amountInput.Focus()
 						}
 					}
@@ -377,7 +552,7 @@ func (m addMealModel) Update(msg tea.Msg) (addMealModel, tea.Cmd) {
 			}
 
 		case stepAmount:
-			servings := productServings(m.selected)
+			servings := m.servings()
 			if m.servingFocused {
 				switch msg.String() {
 				case "esc":
@@ -398,7 +573,7 @@ func (m addMealModel) Update(msg tea.Msg) (addMealModel, tea.Cmd) {
 				case "tab", "down", "enter":
 					m.servingFocused = false
 					m.amountInput.Focus()
-					m.amountInput.SetValue(defaultAmountForServing(servings[m.servingIdx]))
+					m.amountInput.SetValue(models.DefaultServingAmount(servings[m.servingIdx]))
 				}
 			} else {
 				switch msg.String() {
@@ -461,10 +636,11 @@ func (m addMealModel) Update(msg tea.Msg) (addMealModel, tea.Cmd) {
 		} else {
 			p := msg.product
 			m.selected = p
+			m.selectedRecipe = false
 			m.step = stepAmount
 			m.servingIdx = 0
 			m.err = ""
-			servings := productServings(p)
+			servings := models.ProductServings(p)
 			if m.editConsumedID != "" {
 				// Edit mode: pre-select serving and pre-fill amount
 				for i, s := range servings {
@@ -479,16 +655,20 @@ func (m addMealModel) Update(msg tea.Msg) (addMealModel, tea.Cmd) {
 			} else if len(servings) > 1 {
 				m.servingFocused = true
 				m.amountInput.Blur()
-				m.amountInput.SetValue(defaultAmountForServing(servings[0]))
+				m.amountInput.SetValue(models.DefaultServingAmount(servings[0]))
 			} else {
 				m.servingFocused = false
-				m.amountInput.SetValue(defaultAmountForServing(servings[0]))
+				m.amountInput.SetValue(models.DefaultServingAmount(servings[0]))
 				m.amountInput.Focus()
 			}
 		}
 
 	case addSuccessMsg:
-		return m, func() tea.Msg { return addedMealMsg{} }
+		c := change{verb: "add", date: m.date.Format(time.DateOnly), after: []models.DiaryEntry{msg.entry}}
+		if m.editConsumedID != "" {
+			c.verb, c.before = "edit", []models.DiaryEntry{m.editEntry}
+		}
+		return m, func() tea.Msg { return addedMealMsg{change: c} }
 
 	case addErrMsg:
 		m.loading = false
@@ -500,6 +680,18 @@ func (m addMealModel) Update(msg tea.Msg) (addMealModel, tea.Cmd) {
 	var cmd tea.Cmd
 	m.searchInput, cmd = m.searchInput.Update(msg)
 	cmds = append(cmds, cmd)
+	m.countryInput, cmd = m.countryInput.Update(msg)
+	cmds = append(cmds, cmd)
+	m.languageInput, cmd = m.languageInput.Update(msg)
+	cmds = append(cmds, cmd)
+	m.minProtein, cmd = m.minProtein.Update(msg)
+	cmds = append(cmds, cmd)
+	m.maxKcal, cmd = m.maxKcal.Update(msg)
+	cmds = append(cmds, cmd)
+	// Editing a filter can hide the selected result
+	if m.tab == tabSearch {
+		m.listIdx = min(m.listIdx, max(0, m.listLen()-1))
+	}
 	m.amountInput, cmd = m.amountInput.Update(msg)
 	cmds = append(cmds, cmd)
 
@@ -532,7 +724,39 @@ func (m addMealModel) View() string {
 		sb.WriteString("\n\n")
 
 		if m.tab == tabSearch {
-			sb.WriteString(styleInput.Width(m.searchInput.Width).Render(m.searchInput.View()) + "\n\n")
+			sb.WriteString(styleInput.Width(m.searchInput.Width).Render(m.searchInput.View()) + "\n")
+			countryLabel, languageLabel := styleDimmed.Render("Country "), styleDimmed.Render("Language ")
+			if m.countryInput.Focused() {
+				countryLabel = styleSelected.Render("Country") + " "
+			}
+			if m.languageInput.Focused() {
+				languageLabel = styleSelected.Render("Language") + " "
+			}
+			sb.WriteString(fmt.Sprintf("  %s%s  %s%s\n",
+				countryLabel, m.countryInput.View(), languageLabel, m.languageInput.View()))
+			proteinLabel, kcalLabel := styleDimmed.Render("Min protein "), styleDimmed.Render("Max kcal ")
+			if m.minProtein.Focused() {
+				proteinLabel = styleSelected.Render("Min protein") + " "
+			}
+			if m.maxKcal.Focused() {
+				kcalLabel = styleSelected.Render("Max kcal") + " "
+			}
+			verified := styleDimmed.Render("all products")
+			if m.verifiedOnly {
+				verified = lipgloss.NewStyle().Foreground(colorSuccess).Render("✓ verified only")
+			}
+			sb.WriteString(fmt.Sprintf("  %s%s %s  %s%s  %s  %s\n\n",
+				proteinLabel, m.minProtein.View(), styleDimmed.Render("g/100g"),
+				kcalLabel, m.maxKcal.View(), verified,
+				styleDimmed.Render("sorted by "+m.resultSort.Label())))
+		}
+
+		if m.tab == tabRecent {
+			filter := "All meals"
+			if mt := m.recentMealTime(); mt != "" {
+				filter = models.MealTimeLabel(mt)
+			}
+			sb.WriteString(styleDimmed.Render(fmt.Sprintf("  %s · last %d days", filter, m.recentDays)) + "\n\n")
 		}
 
 		if m.fetchingProduct {
@@ -542,10 +766,13 @@ func (m addMealModel) View() string {
 		} else if m.err != "" {
 			sb.WriteString(styleError.Render("  "+m.err) + "\n")
 		} else {
-			list := m.currentList()
-			if len(list) == 0 {
+			n := m.listLen()
+			results := m.visibleResults()
+			if n == 0 {
 				if m.tab == tabRecent {
 					sb.WriteString(styleDimmed.Render("  No recent foods") + "\n")
+				} else if len(m.results) > 0 {
+					sb.WriteString(styleDimmed.Render(fmt.Sprintf("  None of the %d results match the filters", len(m.results))) + "\n")
 				} else {
 					sb.WriteString(styleDimmed.Render("  Type to search and press Enter") + "\n")
 				}
@@ -559,35 +786,48 @@ func (m addMealModel) View() string {
 					start = m.listIdx - maxVisible + 1
 				}
 				end := start + maxVisible
-				if end > len(list) {
-					end = len(list)
+				if end > n {
+					end = n
 				}
 				for i := start; i < end; i++ {
-					p := list[i]
-					kcalPer100 := fmt.Sprintf("%.0f kcal/100g", p.Nutrients.EnergyKcal*100)
-					name := truncate(p.Name, m.width-20)
-					line := fmt.Sprintf("  %s  %s", padRight(name, m.width/2), styleDimmed.Render(kcalPer100))
-					if i == m.listIdx && !m.searchInput.Focused() {
+					var line string
+					if m.tab == tabSearch {
+						line = m.resultLine(results[i])
+					} else {
+						line = m.recentLine(m.recent[i])
+					}
+					if i == m.listIdx && (m.tab != tabSearch || m.searchField() < 0) {
 						line = styleSelected.Render(line)
 					}
 					sb.WriteString(line + "\n")
 				}
+				if m.tab == tabSearch {
+					if m.loadingMore {
+						sb.WriteString(styleDimmed.Render("  Loading more...") + "\n")
+					} else if m.searchHasMore && end == n {
+						sb.WriteString(styleDimmed.Render("  ↓ more results") + "\n")
+					}
+				}
 			}
 		}
 
 		sb.WriteString("\n")
-		sb.WriteString(styleHelp.Render("[Tab] switch tab  [↑/↓] navigate  [Enter] select  [Esc] back"))
+		if m.tab == tabSearch {
+			sb.WriteString(styleHelp.Render("[Tab] switch tab  [↑/↓] navigate  [Enter] select  [ctrl+o] locale/filters  [ctrl+v] verified  [ctrl+s] sort  [Esc] back"))
+		} else {
+			sb.WriteString(styleHelp.Render("[Tab] switch tab  [↑/↓] navigate  [Enter] select  [m] meal filter  [Esc] back"))
+		}
 
 	case stepAmount:
 		if m.selected != nil {
 			sb.WriteString(fmt.Sprintf("  %s\n\n", styleItemName.Render(m.selected.Name)))
 		}
 
-		servings := productServings(m.selected)
+		servings := m.servings()
 		if len(servings) > 1 {
 			sb.WriteString("  Serving:\n  ")
 			for i, s := range servings {
-				label := servingDisplayName(s)
+				label := models.ServingLabel(s)
 				if i == m.servingIdx {
 					if m.servingFocused {
 						sb.WriteString(styleSelected.Render(" " + label + " "))
@@ -606,15 +846,19 @@ func (m addMealModel) View() string {
 
 		if !m.servingFocused {
 			currentS := servings[m.servingIdx]
-			unit := servingDisplayName(currentS)
+			unit := models.ServingLabel(currentS)
 			sb.WriteString(fmt.Sprintf("  Amount (%s):\n", unit))
 			sb.WriteString(styleInput.Width(m.amountInput.Width).Render(m.amountInput.View()))
 
 			qty, _ := strconv.ParseFloat(m.amountInput.Value(), 64)
 			if qty > 0 && m.selected != nil {
-				amountG := amountGramsForServing(qty, currentS)
+				amountG := models.ServingGrams(qty, currentS)
 				kcal := m.selected.Nutrients.EnergyKcal * amountG
-				sb.WriteString("  " + styleDimmed.Render(fmt.Sprintf("= %.0fg · %.0f kcal", amountG, kcal)) + "\n\n")
+				if m.selectedRecipe {
+					sb.WriteString("  " + styleDimmed.Render(fmt.Sprintf("= %.0f kcal", kcal)) + "\n\n")
+				} else {
+					sb.WriteString("  " + styleDimmed.Render(fmt.Sprintf("= %.0fg · %.0f kcal", amountG, kcal)) + "\n\n")
+				}
 			} else {
 				sb.WriteString("\n\n")
 			}
@@ -634,13 +878,17 @@ func (m addMealModel) View() string {
 
 	case stepMealTime:
 		if m.selected != nil {
-			servings := productServings(m.selected)
+			servings := m.servings()
 			s := servings[m.servingIdx]
 			qty, _ := strconv.ParseFloat(m.amountInput.Value(), 64)
-			amountG := amountGramsForServing(qty, s)
+			amountG := models.ServingGrams(qty, s)
 			kcal := m.selected.Nutrients.EnergyKcal * amountG
-			sb.WriteString(fmt.Sprintf("  %s  —  %.0fg  —  %.0f kcal\n\n",
-				styleItemName.Render(m.selected.Name), amountG, kcal))
+			amount := fmt.Sprintf("%.0fg", amountG)
+			if m.selectedRecipe {
+				amount = fmt.Sprintf("%g portion(s)", qty)
+			}
+			sb.WriteString(fmt.Sprintf("  %s  —  %s  —  %.0f kcal\n\n",
+				styleItemName.Render(m.selected.Name), amount, kcal))
 		}
 		sb.WriteString("  Meal:\n  ")
 		for i, mt := range models.MealTimes {
@@ -667,6 +915,42 @@ func (m addMealModel) View() string {
 	return sb.String()
 }
 
+// resultLine renders a search result: name and producer, a check mark when
+// verified, then kcal and protein per 100 g.
+func (m addMealModel) resultLine(p models.ProductResponse) string {
+	name := p.Name
+	if p.Producer != "" {
+		name += " · " + p.Producer
+	}
+	mark := " "
+	if p.IsVerified {
+		mark = lipgloss.NewStyle().Foreground(colorSuccess).Render("✓")
+	}
+	info := fmt.Sprintf("%.0f kcal · %.1fg protein /100g", p.Nutrients.EnergyKcal*100, p.Nutrients.Protein*100)
+	if m.resultSort == search.SortProtein {
+		info += fmt.Sprintf(" · %.1fg/100kcal", search.ProteinPerKcal(p))
+	}
+	return fmt.Sprintf("  %s %s  %s", padRight(truncate(name, m.width/2), m.width/2), mark, styleDimmed.Render(info))
+}
+
+// recentLine renders a Recent tab row: name, energy density and how often
+// and how recently the item was logged.
+func (m addMealModel) recentLine(it recentItem) string {
+	kcal := fmt.Sprintf("%.0f kcal/100g", it.product.Nutrients.EnergyKcal*100)
+	if it.key.recipe {
+		kcal = fmt.Sprintf("%.0f kcal/portion", it.product.Nutrients.EnergyKcal)
+	}
+	name := it.product.Name
+	if it.key.recipe {
+		name += " (recipe)"
+	}
+	name = truncate(name, m.width-20)
+	logged := fmt.Sprintf("%d× · %s", it.count, lastEatenLabel(it.last, m.date))
+	return fmt.Sprintf("  %s  %s  %s", padRight(name, m.width/2), styleDimmed.Render(padRight(kcal, 16)), styleDimmed.Render(logged))
+}
+
 // Messages for page transitions
 type backToDiaryMsg struct{}
-type addedMealMsg struct{}
+
+// addedMealMsg reports an add or edit, for the diary's undo history.
+type addedMealMsg struct{ change change }
@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"errors"
 	"sync"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -16,9 +17,16 @@ const (
 	pageDiary   page = 1
 	pageAddMeal page = 2
 	pageDebug   page = 3
+	pageWeek    page = 4
+	pageMonth   page = 5
+	pageTrends  page = 6
 )
 
-type profileLoadedMsg struct{ profile *models.UserProfile }
+// profileLoadedMsg reports the user's profile, which searches need.
+type profileLoadedMsg struct {
+	profile *models.UserProfile
+	err     string
+}
 type sessionExpiredMsg struct{}
 
 type App struct {
@@ -27,7 +35,11 @@ type App struct {
 	diary   diaryModel
 	addMeal addMealModel
 	debug   debugModel
+	week    weekModel
+	month   monthModel
+	trends  trendsModel
 	client  *api.Client
+	history *api.History // last HTTP calls, shown on the debug page
 	token   string
 	profile *models.UserProfile
 	cache   *sync.Map
@@ -37,7 +49,13 @@ type App struct {
 
 func fetchProfile(client *api.Client) tea.Cmd {
 	return func() tea.Msg {
-		p, _ := client.GetProfile()
+		p, err := client.GetProfile()
+		if err != nil {
+			if errors.Is(err, api.ErrSessionExpired) {
+				return sessionExpiredMsg{}
+			}
+			return profileLoadedMsg{err: err.Error()}
+		}
 		return profileLoadedMsg{profile: p}
 	}
 }
@@ -47,9 +65,11 @@ func New(loggedIn bool, token, email, refreshToken string) *App {
 	var p page
 	var diary diaryModel
 	var client *api.Client
+	history := api.NewHistory(historySize)
 
 	if loggedIn {
 		client = api.New(token)
+		client.SetHistory(history)
 		client.SetRefresh(refreshToken, func(accessToken, newRefreshToken string) {
 			auth.SaveToken(email, accessToken, newRefreshToken)
 		})
@@ -60,19 +80,19 @@ func New(loggedIn bool, token, email, refreshToken string) *App {
 	}
 
 	return &App{
-		page:   p,
-		login:  newLoginModel(),
-		diary:  diary,
-		cache:  cache,
-		client: client,
-		token:  token,
+		page:    p,
+		login:   newLoginModel(),
+		diary:   diary,
+		cache:   cache,
+		client:  client,
+		history: history,
+		token:   token,
 	}
 }
 
 func (a *App) Init() tea.Cmd {
 	if a.page == pageDiary {
-		a.diary.loading = true
-		return tea.Batch(a.diary.loadDiary(), fetchProfile(a.client))
+		return tea.Batch(a.diary.reload(), fetchProfile(a.client))
 	}
 	return nil
 }
@@ -94,16 +114,46 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if msg.String() == "ctrl+c" {
 			return a, tea.Quit
 		}
+		// The copy form takes text and m waits for a meal number; don't
+		// treat those keys as page keys
+		if a.page == pageDiary && a.diary.capturesKeys() {
+			break
+		}
 		if a.page == pageDiary && msg.String() == "q" {
 			return a, tea.Quit
 		}
 
 		// Debug page
 		if a.page == pageDiary && msg.String() == "?" {
-			a.debug = newDebugModel(a.token)
+			a.debug = newDebugModel(a.token, a.history, a.client, a.debug.tickID+1)
 			a.debug.width, a.debug.height = a.width, a.height
 			a.page = pageDebug
-			return a, nil
+			return a, a.debug.tick()
+		}
+
+		// Week overview
+		if a.page == pageDiary && msg.String() == "w" {
+			a.week = newWeekModel(a.client, a.diary.date)
+			a.week.width, a.week.height = a.width, a.height
+			a.page = pageWeek
+			return a, a.week.load()
+		}
+
+		// Month calendar
+		if a.page == pageDiary && msg.String() == "M" {
+			a.month = newMonthModel(a.client, a.diary.date)
+			a.month.width, a.month.height = a.width, a.height
+			a.page = pageMonth
+			return a, a.month.load()
+		}
+
+		// Trend charts
+		if a.page == pageDiary && msg.String() == "T" {
+			var cmd tea.Cmd
+			a.trends, cmd = newTrendsModel(a.client, a.diary.date).ensureLoaded()
+			a.trends.width, a.trends.height = a.width, a.height
+			a.page = pageTrends
+			return a, cmd
 		}
 
 		// Page-specific add key
@@ -113,46 +163,73 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			a.addMeal.loading = true
 			a.page = pageAddMeal
 			cmds = append(cmds, a.addMeal.loadRecent())
+			if a.profile == nil {
+				// Still loading or failed at startup; searching needs it
+				cmds = append(cmds, fetchProfile(a.client))
+			}
 			return a, tea.Batch(cmds...)
 		}
 
 	case profileLoadedMsg:
+		if msg.err != "" {
+			if a.page == pageAddMeal {
+				a.addMeal.err = "couldn't load your profile: " + msg.err
+			}
+			return a, nil
+		}
 		a.profile = msg.profile
+		if a.page == pageAddMeal {
+			a.addMeal.setProfile(msg.profile)
+		}
 
 	// Login flow
 	case loginSuccessMsg:
 		a.token = msg.token
 		a.client = api.New(msg.token)
+		a.client.SetHistory(a.history)
 		email, refreshToken := msg.email, msg.refreshToken
 		a.client.SetRefresh(refreshToken, func(accessToken, newRefreshToken string) {
 			auth.SaveToken(email, accessToken, newRefreshToken)
 		})
+		loadID := a.diary.loadID
+		a.diary.stopLoading()
 		a.diary = newDiaryModel(a.client, a.cache)
+		// Keep counting, so a result from before the re-login is dropped
+		a.diary.loadID = loadID
 		a.diary.width, a.diary.height = a.width, a.height
-		a.diary.loading = true
 		a.page = pageDiary
-		return a, tea.Batch(a.diary.loadDiary(), fetchProfile(a.client))
+		return a, tea.Batch(a.diary.reload(), fetchProfile(a.client))
 
 	// Add meal transitions
 	case backToDiaryMsg:
 		a.page = pageDiary
-		a.diary.loading = true
-		return a, a.diary.loadDiary()
+		return a, a.diary.reload()
 
 	case addedMealMsg:
 		a.page = pageDiary
 		// Reload diary to show new item
 		a.diary.date = a.addMeal.date
-		a.diary.loading = true
-		return a, a.diary.loadDiary()
+		a.diary.record(msg.change)
+		return a, a.diary.reload()
+
+	case openDayMsg:
+		a.page = pageDiary
+		a.diary.date = msg.date
+		a.diary.selected = 0
+		a.diary.clearMarks()
+		return a, a.diary.reload()
 
 	case editEntryMsg:
 		a.addMeal = newEditMealModel(a.client, a.cache, a.diary.date, a.profile, msg.entry)
 		a.addMeal.width, a.addMeal.height = a.width, a.height
 		a.page = pageAddMeal
+		if a.profile == nil {
+			return a, tea.Batch(a.addMeal.doFetchProduct(msg.entry.ProductID), fetchProfile(a.client))
+		}
 		return a, a.addMeal.doFetchProduct(msg.entry.ProductID)
 
 	case logoutMsg:
+		a.diary.stopLoading()
 		auth.ClearToken()
 		a.token = ""
 		a.client = nil
@@ -164,6 +241,7 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return a, nil
 
 	case sessionExpiredMsg:
+		a.diary.stopLoading()
 		auth.ClearToken()
 		a.token = ""
 		a.client = nil
@@ -197,6 +275,21 @@ func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		var cmd tea.Cmd
 		a.debug, cmd = a.debug.Update(msg)
 		cmds = append(cmds, cmd)
+
+	case pageWeek:
+		var cmd tea.Cmd
+		a.week, cmd = a.week.Update(msg)
+		cmds = append(cmds, cmd)
+
+	case pageMonth:
+		var cmd tea.Cmd
+		a.month, cmd = a.month.Update(msg)
+		cmds = append(cmds, cmd)
+
+	case pageTrends:
+		var cmd tea.Cmd
+		a.trends, cmd = a.trends.Update(msg)
+		cmds = append(cmds, cmd)
 	}
 
 	return a, tea.Batch(cmds...)
@@ -212,7 +305,12 @@ func (a *App) View() string {
 		return a.addMeal.View()
 	case pageDebug:
 		return a.debug.View()
+	case pageWeek:
+		return a.week.View()
+	case pageMonth:
+		return a.month.View()
+	case pageTrends:
+		return a.trends.View()
 	}
 	return ""
 }
-
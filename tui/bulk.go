@@ -0,0 +1,306 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/koriwi/yazio-cli/internal/api"
+	"github.com/koriwi/yazio-cli/internal/diary"
+	"github.com/koriwi/yazio-cli/internal/models"
+)
+
+// bulkKind is an action on several entries at once.
+type bulkKind int
+
+const (
+	bulkDelete bulkKind = iota
+	bulkMove
+	bulkCopy
+	bulkScale
+)
+
+// bulkAction is an action waiting for confirmation. reqs are the new entries
+// for everything but delete.
+type bulkAction struct {
+	kind    bulkKind
+	title   string
+	entries []models.DiaryEntry
+	reqs    []models.AddConsumedRequest
+	dates   []time.Time // copy targets
+}
+
+// maxConfirmLines is how many affected entries the confirmation lists.
+const maxConfirmLines = 8
+
+// entriesDeletedMsg reports the result of deleting entries at once.
+type entriesDeletedMsg struct {
+	entries []models.DiaryEntry
+	date    string
+	err     string
+}
+
+// markedEntries returns the marked entries in list order.
+func (m diaryModel) markedEntries() []models.DiaryEntry {
+	var out []models.DiaryEntry
+	for _, e := range m.entries {
+		if m.marked[e.ConsumedID] {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// targets returns the entries an action applies to: the marked ones, or the
+// selected one when nothing is marked.
+func (m diaryModel) targets() []models.DiaryEntry {
+	if marked := m.markedEntries(); len(marked) > 0 {
+		return marked
+	}
+	if len(m.entries) == 0 || m.entries[m.selected].ConsumedID == "" {
+		return nil
+	}
+	return m.entries[m.selected : m.selected+1]
+}
+
+// toggleMark marks or unmarks the selected entry and makes it the anchor of
+// the next range.
+func (m *diaryModel) toggleMark() {
+	if len(m.entries) == 0 {
+		return
+	}
+	id := m.entries[m.selected].ConsumedID
+	if id == "" {
+		return
+	}
+	if m.marked[id] {
+		delete(m.marked, id)
+	} else {
+		m.marked[id] = true
+	}
+	m.anchor = id
+}
+
+// markRange marks every entry from the anchor to the selected one.
+func (m *diaryModel) markRange() {
+	if len(m.entries) == 0 {
+		return
+	}
+	from := m.selected
+	for i, e := range m.entries {
+		if e.ConsumedID == m.anchor {
+			from = i
+		}
+	}
+	lo, hi := min(from, m.selected), max(from, m.selected)
+	for _, e := range m.entries[lo : hi+1] {
+		if e.ConsumedID != "" {
+			m.marked[e.ConsumedID] = true
+		}
+	}
+	m.anchor = m.entries[m.selected].ConsumedID
+}
+
+func (m *diaryModel) clearMarks() {
+	m.marked = map[string]bool{}
+	m.anchor = ""
+}
+
+// pruneMarks unmarks entries that are gone from the list, e.g. because they
+// were edited or deleted elsewhere.
+func (m *diaryModel) pruneMarks() {
+	shown := map[string]bool{}
+	for _, e := range m.entries {
+		shown[e.ConsumedID] = true
+	}
+	for id := range m.marked {
+		if !shown[id] {
+			delete(m.marked, id)
+		}
+	}
+	if !shown[m.anchor] {
+		m.anchor = ""
+	}
+}
+
+func (m diaryModel) confirmDelete(entries []models.DiaryEntry) diaryModel {
+	m.confirm = &bulkAction{kind: bulkDelete, title: fmt.Sprintf("Delete %d item(s)?", len(entries)), entries: entries}
+	return m
+}
+
+func (m diaryModel) confirmMove(entries []models.DiaryEntry, mealTime string) diaryModel {
+	var moving []models.DiaryEntry
+	for _, e := range entries {
+		if e.MealTime != mealTime {
+			moving = append(moving, e)
+		}
+	}
+	if len(moving) == 0 {
+		m.status = "Already in " + models.MealTimeLabel(mealTime)
+		return m
+	}
+	m.confirm = &bulkAction{
+		kind:    bulkMove,
+		title:   fmt.Sprintf("Move %d item(s) to %s?", len(moving), models.MealTimeLabel(mealTime)),
+		entries: moving,
+		reqs:    diary.CopyRequests(moving, []time.Time{m.date}, mealTime),
+	}
+	return m
+}
+
+func (m diaryModel) confirmCopy(entries []models.DiaryEntry, dates []time.Time, mealTime string) diaryModel {
+	to := dates[0].Format(time.DateOnly)
+	if len(dates) > 1 {
+		to = fmt.Sprintf("%s – %s (%d days)", to, dates[len(dates)-1].Format(time.DateOnly), len(dates))
+	}
+	if mealTime != "" {
+		to += ", as " + models.MealTimeLabel(mealTime)
+	}
+	m.confirm = &bulkAction{
+		kind:    bulkCopy,
+		title:   fmt.Sprintf("Copy %d item(s) to %s?", len(entries), to),
+		entries: entries,
+		reqs:    diary.CopyRequests(entries, dates, mealTime),
+		dates:   dates,
+	}
+	return m
+}
+
+// startScale asks by how much to scale the targets.
+func (m diaryModel) startScale() diaryModel {
+	if len(m.targets()) == 0 {
+		return m
+	}
+	input := textinput.New()
+	input.Placeholder = "100"
+	input.CharLimit = 5
+	input.Width = 6
+	input.Prompt = ""
+	input.Focus()
+	m.scaleInput = input
+	m.scaling = true
+	return m
+}
+
+func (m diaryModel) updateScale(msg tea.KeyMsg) (diaryModel, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.scaling = false
+		return m, nil
+	case "enter":
+		pct, err := strconv.ParseFloat(strings.TrimSpace(m.scaleInput.Value()), 64)
+		if err != nil || pct <= 0 || pct > 1000 || pct == 100 {
+			return m, nil
+		}
+		entries := m.targets()
+		m.scaling = false
+		m.confirm = &bulkAction{
+			kind:    bulkScale,
+			title:   fmt.Sprintf("Scale %d item(s) to %g%%?", len(entries), pct),
+			entries: entries,
+			reqs:    diary.ScaleRequests(entries, m.date, pct/100),
+		}
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.scaleInput, cmd = m.scaleInput.Update(msg)
+	return m, cmd
+}
+
+func (m diaryModel) updateConfirm(msg tea.KeyMsg) (diaryModel, tea.Cmd) {
+	a := m.confirm
+	switch msg.String() {
+	case "y", "enter":
+		m.confirm = nil
+		m.clearMarks()
+		switch a.kind {
+		case bulkDelete:
+			return m, m.deleteEntries(a.entries)
+		case bulkMove:
+			return m, m.replaceEntries(a.entries, a.reqs, "move")
+		case bulkScale:
+			return m, m.replaceEntries(a.entries, a.reqs, "scale")
+		case bulkCopy:
+			m.status = fmt.Sprintf("Copying %d item(s)…", len(a.reqs))
+			return m, copyEntries(m.client, a.reqs, a.dates)
+		}
+	case "n", "esc":
+		m.confirm = nil
+	}
+	return m, nil
+}
+
+// deleteEntries removes entries from the list right away and deletes them
+// in one request; entriesDeletedMsg reloads the day if that fails.
+func (m *diaryModel) deleteEntries(entries []models.DiaryEntry) tea.Cmd {
+	ids := make([]string, len(entries))
+	gone := map[string]bool{}
+	for i, e := range entries {
+		ids[i] = e.ConsumedID
+		gone[e.ConsumedID] = true
+		m.pendingDeletes[e.ConsumedID] = true
+	}
+	var kept []models.DiaryEntry
+	for _, e := range m.entries {
+		if gone[e.ConsumedID] {
+			m.totals = addToTotals(m.totals, e, -1)
+		} else {
+			kept = append(kept, e)
+		}
+	}
+	m.entries = kept
+	m.selected = min(m.selected, max(0, len(m.entries)-1))
+	m.remember()
+
+	client := m.client
+	date := m.shown
+	return func() tea.Msg {
+		if err := client.DeleteConsumedItems(ids); err != nil {
+			if errors.Is(err, api.ErrSessionExpired) {
+				return sessionExpiredMsg{}
+			}
+			return entriesDeletedMsg{entries: entries, date: date, err: "delete failed: " + err.Error()}
+		}
+		return entriesDeletedMsg{entries: entries, date: date}
+	}
+}
+
+// bulkView renders the scale prompt or the confirmation with the entries it
+// affects below the date navigation.
+func (m diaryModel) bulkView() string {
+	var sb strings.Builder
+	if m.scaling {
+		label := lipgloss.NewStyle().Foreground(colorMuted)
+		input := lipgloss.JoinHorizontal(lipgloss.Center,
+			label.Render(fmt.Sprintf("Scale %d item(s) to ", len(m.targets()))), styleInput.Render(m.scaleInput.View()), label.Render(" % of their amount"))
+		sb.WriteString(input + "\n\n")
+	}
+	if a := m.confirm; a != nil {
+		sb.WriteString(styleHeader.Render(a.title) + "\n")
+		for i, e := range a.entries {
+			if i == maxConfirmLines {
+				sb.WriteString(styleDimmed.Render(fmt.Sprintf("  … and %d more", len(a.entries)-i)) + "\n")
+				break
+			}
+			amount := models.FormatServing(e.Amount, e.Serving, e.ServingQuantity)
+			line := "  " + e.Name + " · "
+			switch a.kind {
+			case bulkMove:
+				line += amount + " · " + models.MealTimeLabel(e.MealTime) + " → " + models.MealTimeLabel(a.reqs[i].Daytime)
+			case bulkScale:
+				r := a.reqs[i]
+				line += amount + " → " + models.FormatServing(r.Amount, r.Serving, r.ServingQuantity)
+			default:
+				line += amount + fmt.Sprintf(" · %.0f kcal", e.Kcal)
+			}
+			sb.WriteString(styleItemName.Render(line) + "\n")
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
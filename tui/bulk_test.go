@@ -0,0 +1,114 @@
+package tui
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/koriwi/yazio-cli/internal/api"
+	"github.com/koriwi/yazio-cli/internal/models"
+)
+
+func key(s string) tea.KeyMsg {
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+func testEntries(ids ...string) []models.DiaryEntry {
+	var entries []models.DiaryEntry
+	for _, id := range ids {
+		entries = append(entries, models.DiaryEntry{ConsumedID: id, ProductID: "p-" + id, Name: "Food " + id,
+			MealTime: "breakfast", Amount: 100, Serving: "gram", ServingQuantity: 100, Kcal: 100})
+	}
+	return entries
+}
+
+// loadedDiary returns a diary page showing entries, as after a load. Its
+// commands are never run, so the client doesn't need a server.
+func loadedDiary(entries []models.DiaryEntry) diaryModel {
+	m := newDiaryModel(api.New("token"), &sync.Map{})
+	m.date = time.Date(2024, 1, 15, 0, 0, 0, 0, time.Local)
+	m.reload()
+	m, _ = m.Update(diaryLoadedMsg{id: m.loadID, date: m.date, entries: entries})
+	return m
+}
+
+func markedIDs(m diaryModel) []string {
+	var ids []string
+	for _, e := range m.markedEntries() {
+		ids = append(ids, e.ConsumedID)
+	}
+	return ids
+}
+
+func TestReloadPrunesMarks(t *testing.T) {
+	m := loadedDiary(testEntries("a", "b", "c"))
+	m, _ = m.Update(key(" "))
+	m, _ = m.Update(key("j"))
+	m, _ = m.Update(key(" "))
+	if got := markedIDs(m); len(got) != 2 {
+		t.Fatalf("marked %v, want a and b", got)
+	}
+
+	// a was edited, which gives it a new ID
+	m.reload()
+	m, _ = m.Update(diaryLoadedMsg{id: m.loadID, date: m.date, entries: testEntries("a2", "b", "c")})
+	if len(m.marked) != 1 || !m.marked["b"] {
+		t.Errorf("after an edit: marked %v, want only b", m.marked)
+	}
+
+	// b was deleted elsewhere
+	m.reload()
+	m, _ = m.Update(diaryLoadedMsg{id: m.loadID, date: m.date, entries: testEntries("a2", "c")})
+	if len(m.marked) != 0 || m.anchor != "" {
+		t.Errorf("after a delete elsewhere: marked %v, anchor %q, want none", m.marked, m.anchor)
+	}
+}
+
+func TestStaleMarksAreIgnored(t *testing.T) {
+	stale := func() diaryModel {
+		m := loadedDiary(testEntries("a", "b"))
+		m.marked["gone"] = true
+		return m
+	}
+
+	m, _ := stale().Update(key("d"))
+	if m.confirm != nil {
+		t.Errorf("d asked %q, want the selected entry deleted", m.confirm.title)
+	}
+	if len(m.entries) != 1 || m.entries[0].ConsumedID != "b" {
+		t.Errorf("after d: entries %v, want only b", m.entries)
+	}
+
+	m, _ = stale().Update(key("m"))
+	m, _ = m.Update(key("2"))
+	if m.confirm != nil {
+		t.Errorf("m2 asked %q, want the selected entry moved", m.confirm.title)
+	}
+	if e := m.entries[len(m.entries)-1]; e.MealTime != "lunch" {
+		t.Errorf("after m2: %s is in %s, want lunch", e.Name, e.MealTime)
+	}
+
+	m, _ = stale().Update(key("c"))
+	if !m.copying {
+		t.Fatal("c didn't open the copy form")
+	}
+	if view := m.copyView(); strings.Contains(view, "Selected") || strings.Contains(view, "nothing to copy") {
+		t.Errorf("copy form with stale marks:\n%s", view)
+	}
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	if m.copyForm.scope != copyMeal {
+		t.Errorf("tab: scope %d, want the meal", m.copyForm.scope)
+	}
+}
+
+func TestOpenDayClearsMarks(t *testing.T) {
+	a := New(false, "", "", "")
+	a.diary = loadedDiary(testEntries("a", "b"))
+	a.diary.toggleMark()
+	a.Update(openDayMsg{date: time.Date(2024, 1, 10, 0, 0, 0, 0, time.Local)})
+	if len(a.diary.marked) != 0 || a.diary.anchor != "" {
+		t.Errorf("marked %v, anchor %q after opening another day", a.diary.marked, a.diary.anchor)
+	}
+}
@@ -0,0 +1,161 @@
+package tui
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// sparkBlocks are the eight bar heights used by charts, lowest first.
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// bucket averages values into n columns, skipping NaN (missing) values. A
+// column with no values is NaN. With fewer values than n, every value gets
+// its own column and the result is shorter than n.
+func bucket(values []float64, n int) []float64 {
+	if n <= 0 || len(values) == 0 {
+		return nil
+	}
+	if len(values) <= n {
+		return values
+	}
+	out := make([]float64, n)
+	for i := range out {
+		from, to := i*len(values)/n, (i+1)*len(values)/n
+		sum, count := 0.0, 0
+		for _, v := range values[from:to] {
+			if !math.IsNaN(v) {
+				sum += v
+				count++
+			}
+		}
+		out[i] = math.NaN()
+		if count > 0 {
+			out[i] = sum / float64(count)
+		}
+	}
+	return out
+}
+
+// stretch repeats every value so that values fill about n columns, for
+// ranges shorter than the chart is wide.
+func stretch(values []float64, n int) []float64 {
+	k := 1
+	if len(values) > 0 {
+		k = max(1, n/len(values))
+	}
+	out := make([]float64, 0, len(values)*k)
+	for _, v := range values {
+		for i := 0; i < k; i++ {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// movingAverage returns the trailing average over window values for each
+// value, ignoring NaN values. The first window-1 inputs only warm it up: the
+// result starts at values[window-1].
+func movingAverage(values []float64, window int) []float64 {
+	if window < 1 || len(values) < window {
+		return nil
+	}
+	out := make([]float64, len(values)-window+1)
+	for i := range out {
+		sum, count := 0.0, 0
+		for _, v := range values[i : i+window] {
+			if !math.IsNaN(v) {
+				sum += v
+				count++
+			}
+		}
+		out[i] = math.NaN()
+		if count > 0 {
+			out[i] = sum / float64(count)
+		}
+	}
+	return out
+}
+
+// sparkline renders values (NaN = blank) as one line of bars scaled to top.
+func sparkline(values []float64, top float64, color lipgloss.Color) string {
+	var sb strings.Builder
+	for _, v := range values {
+		if math.IsNaN(v) || top <= 0 {
+			sb.WriteRune(' ')
+			continue
+		}
+		i := int(v / top * float64(len(sparkBlocks)-1))
+		sb.WriteRune(sparkBlocks[max(0, min(i, len(sparkBlocks)-1))])
+	}
+	return lipgloss.NewStyle().Foreground(color).Render(sb.String())
+}
+
+// barChart renders values as columns height rows tall with a y axis on the
+// left and from/to labelling the ends of the x axis. avg is drawn as a line
+// of dots over the columns and goal as a dashed line where the columns leave
+// room; NaN skips a point.
+func barChart(values, avg []float64, goal float64, height int, unit, from, to string, color lipgloss.Color) string {
+	top := goal
+	for _, v := range values {
+		if !math.IsNaN(v) {
+			top = math.Max(top, v)
+		}
+	}
+	top *= 1.1
+	if top <= 0 {
+		top = 1
+	}
+
+	// Each row covers top/height; a column fills whole rows up to its value
+	// and a partial block for the remainder.
+	cellValue := top / float64(height)
+	row := func(v float64) int { return int(v / cellValue) }
+
+	barStyle := lipgloss.NewStyle().Foreground(color)
+	avgStyle := lipgloss.NewStyle().Foreground(colorText).Bold(true)
+	goalStyle := lipgloss.NewStyle().Foreground(colorMuted)
+
+	labelW := len(fmt.Sprintf("%.0f", top))
+	var lines []string
+	for r := height - 1; r >= 0; r-- {
+		var sb strings.Builder
+		label := ""
+		switch r {
+		case height - 1:
+			label = fmt.Sprintf("%.0f", top)
+		case 0:
+			label = "0"
+		case row(goal):
+			if goal > 0 {
+				label = fmt.Sprintf("%.0f", goal)
+			}
+		}
+		sb.WriteString(styleDimmed.Render(fmt.Sprintf("  %*s ┤", labelW, label)))
+
+		for i, v := range values {
+			if i < len(avg) && !math.IsNaN(avg[i]) && row(avg[i]) == r {
+				sb.WriteString(avgStyle.Render("•"))
+				continue
+			}
+			switch {
+			case !math.IsNaN(v) && row(v) > r:
+				sb.WriteString(barStyle.Render("█"))
+			case !math.IsNaN(v) && row(v) == r && v > 0:
+				frac := v/cellValue - float64(r)
+				sb.WriteString(barStyle.Render(string(sparkBlocks[int(frac*float64(len(sparkBlocks)-1))])))
+			case goal > 0 && row(goal) == r:
+				sb.WriteString(goalStyle.Render("┄"))
+			default:
+				sb.WriteRune(' ')
+			}
+		}
+		lines = append(lines, sb.String())
+	}
+	lines = append(lines, styleDimmed.Render(fmt.Sprintf("  %*s └%s %s", labelW, "", strings.Repeat("─", len(values)), unit)))
+	gap := max(1, len(values)-len([]rune(from))-len([]rune(to)))
+	lines = append(lines, styleDimmed.Render(fmt.Sprintf("  %*s  %s%s%s", labelW, "", from, strings.Repeat(" ", gap), to)))
+	return strings.Join(lines, "\n")
+}
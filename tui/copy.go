@@ -0,0 +1,192 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/koriwi/yazio-cli/internal/api"
+	"github.com/koriwi/yazio-cli/internal/diary"
+	"github.com/koriwi/yazio-cli/internal/models"
+)
+
+// copyScope is what the copy form copies, relative to the selected entry.
+type copyScope int
+
+const (
+	copyEntry copyScope = iota
+	copyMeal
+	copyDay
+)
+
+var copyScopeLabels = []string{"Entry", "Meal", "Day"}
+
+// copyForm asks where to copy entries of the diary day to.
+type copyForm struct {
+	scope   copyScope
+	target  textinput.Model // day or range, as accepted by diary.ParseDateRange
+	mealIdx int             // 0 keeps each entry's meal, i > 0 is models.MealTimes[i-1]
+	err     string
+}
+
+// copiedMsg reports the result of copying n entries to dates.
+type copiedMsg struct {
+	n     int
+	dates []string
+	err   string
+}
+
+func newCopyForm() copyForm {
+	target := textinput.New()
+	target.Placeholder = "tomorrow, +1, 2024-01-16 or +1..+7"
+	target.CharLimit = 32
+	target.Width = 36
+	target.Prompt = ""
+	target.Focus()
+	return copyForm{target: target}
+}
+
+// copySource returns the marked entries, or those scope covers around the
+// selected entry.
+func (m diaryModel) copySource(scope copyScope) []models.DiaryEntry {
+	if marked := m.markedEntries(); len(marked) > 0 {
+		return marked
+	}
+	if len(m.entries) == 0 {
+		return nil
+	}
+	switch scope {
+	case copyEntry:
+		return m.entries[m.selected : m.selected+1]
+	case copyMeal:
+		return filterByMeal(m.entries, m.entries[m.selected].MealTime)
+	}
+	return m.entries
+}
+
+func (m diaryModel) updateCopy(msg tea.KeyMsg) (diaryModel, tea.Cmd) {
+	f := &m.copyForm
+	switch msg.String() {
+	case "esc":
+		m.copying = false
+		return m, nil
+	case "tab":
+		if len(m.markedEntries()) > 0 {
+			return m, nil
+		}
+		f.scope = (f.scope + 1) % copyScope(len(copyScopeLabels))
+		return m, nil
+	case "shift+tab":
+		if len(m.markedEntries()) > 0 {
+			return m, nil
+		}
+		f.scope = (f.scope + copyScope(len(copyScopeLabels)) - 1) % copyScope(len(copyScopeLabels))
+		return m, nil
+	case "down":
+		f.mealIdx = (f.mealIdx + 1) % (len(models.MealTimes) + 1)
+		return m, nil
+	case "up":
+		f.mealIdx = (f.mealIdx + len(models.MealTimes)) % (len(models.MealTimes) + 1)
+		return m, nil
+	case "enter":
+		source := m.copySource(f.scope)
+		if strings.TrimSpace(f.target.Value()) == "" || len(source) == 0 {
+			return m, nil
+		}
+		dates, err := diary.ParseDateRange(f.target.Value())
+		if err != nil {
+			f.err = err.Error()
+			return m, nil
+		}
+		mealTime := ""
+		if f.mealIdx > 0 {
+			mealTime = models.MealTimes[f.mealIdx-1]
+		}
+		m.copying = false
+		if len(m.markedEntries()) > 0 {
+			return m.confirmCopy(source, dates, mealTime), nil
+		}
+		reqs := diary.CopyRequests(source, dates, mealTime)
+		m.status = fmt.Sprintf("Copying %d item(s)…", len(reqs))
+		return m, copyEntries(m.client, reqs, dates)
+	}
+	var cmd tea.Cmd
+	f.target, cmd = f.target.Update(msg)
+	f.err = ""
+	return m, cmd
+}
+
+// copyEntries adds reqs, which log copies on dates.
+func copyEntries(client *api.Client, reqs []models.AddConsumedRequest, dates []time.Time) tea.Cmd {
+	keys := make([]string, len(dates))
+	for i, d := range dates {
+		keys[i] = d.Format(time.DateOnly)
+	}
+	return func() tea.Msg {
+		n, err := diary.AddAll(client, reqs)
+		if err != nil {
+			if errors.Is(err, api.ErrSessionExpired) {
+				return sessionExpiredMsg{}
+			}
+			return copiedMsg{n: n, dates: keys, err: fmt.Sprintf("copy failed after %d of %d item(s): %v", n, len(reqs), err)}
+		}
+		return copiedMsg{n: n, dates: keys}
+	}
+}
+
+// copyView renders the copy form below the date navigation.
+func (m diaryModel) copyView() string {
+	f := m.copyForm
+	label := lipgloss.NewStyle().Foreground(colorMuted).Width(7)
+
+	var scopes []string
+	for i, s := range copyScopeLabels {
+		if copyScope(i) == f.scope {
+			scopes = append(scopes, styleTabActive.Render(s))
+		} else {
+			scopes = append(scopes, styleTab.Render(s))
+		}
+	}
+
+	source := m.copySource(f.scope)
+	var what string
+	switch {
+	case len(source) == 0:
+		what = "nothing to copy"
+	case len(m.markedEntries()) > 0:
+		what = fmt.Sprintf("%d selected item(s)", len(source))
+	case f.scope == copyEntry:
+		e := source[0]
+		what = e.Name + " · " + models.FormatServing(e.Amount, e.Serving, e.ServingQuantity)
+	case f.scope == copyMeal:
+		what = fmt.Sprintf("%d item(s) of %s", len(source), models.MealTimeLabel(source[0].MealTime))
+	default:
+		what = fmt.Sprintf("all %d item(s) of %s", len(source), formatDate(m.date))
+	}
+	var kcal float64
+	for _, e := range source {
+		kcal += e.Kcal
+	}
+
+	meal := "same meal"
+	if f.mealIdx > 0 {
+		meal = models.MealTimeLabel(models.MealTimes[f.mealIdx-1])
+	}
+
+	var sb strings.Builder
+	if len(m.markedEntries()) > 0 {
+		scopes = []string{styleTabActive.Render("Selected")}
+	}
+	sb.WriteString(label.Render("Copy") + lipgloss.JoinHorizontal(lipgloss.Top, scopes...) + "\n")
+	sb.WriteString(label.Render("") + styleItemName.Render(what) + "  " + styleKcal.Render(fmt.Sprintf("%.0f kcal", kcal)) + "\n")
+	sb.WriteString(lipgloss.JoinHorizontal(lipgloss.Center, label.Render("To"), styleInput.Render(f.target.View())) + "\n")
+	sb.WriteString(label.Render("As") + meal + "\n")
+	if f.err != "" {
+		sb.WriteString(styleError.Render("Error: "+f.err) + "\n")
+	}
+	return sb.String() + "\n"
+}
@@ -1,55 +1,174 @@
 package tui
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/koriwi/yazio-cli/internal/api"
 	"github.com/koriwi/yazio-cli/internal/auth"
 )
 
+// historySize is the number of HTTP calls kept for the request inspector.
+const historySize = 100
+
 type debugModel struct {
 	email      string
 	token      string
 	configPath string
+	history    *api.History
+	calls      []api.Call // snapshot of history, newest first
+	selected   int        // index into calls
+	detail     bool       // showing the selected call's bodies
+	client     *api.Client
+	explorer   explorerModel
+	exploring  bool
 	scrollY    int
+	tickID     int
 	width      int
 	height     int
 }
 
-func newDebugModel(token string) debugModel {
+// debugTickMsg refreshes the request list while the debug page is open.
+type debugTickMsg struct{ id int }
+
+// newDebugModel returns the debug page for one visit. tickID must differ from
+// the previous visit's, so its ticks are dropped.
+func newDebugModel(token string, history *api.History, client *api.Client, tickID int) debugModel {
 	cfg, _ := auth.LoadConfig()
-	return debugModel{
+	m := debugModel{
 		email:      cfg.Email,
 		token:      token,
 		configPath: auth.ConfigFilePath(),
+		history:    history,
+		client:     client,
+		tickID:     tickID,
 	}
+	if history != nil {
+		m.calls = history.Calls()
+	}
+	return m
+}
+
+func (m debugModel) tick() tea.Cmd {
+	id := m.tickID
+	return tea.Tick(time.Second, func(time.Time) tea.Msg { return debugTickMsg{id: id} })
 }
 
 func (m debugModel) Update(msg tea.Msg) (debugModel, tea.Cmd) {
+	if m.exploring {
+		switch msg.(type) {
+		case closeExplorerMsg:
+			m.exploring = false
+			if m.history != nil {
+				m.calls = m.history.Calls()
+			}
+			return m, nil
+		case debugTickMsg:
+			// handled below so the tick chain keeps running
+		default:
+			if size, ok := msg.(tea.WindowSizeMsg); ok {
+				m.width, m.height = size.Width, size.Height
+			}
+			var cmd tea.Cmd
+			m.explorer, cmd = m.explorer.Update(msg)
+			return m, cmd
+		}
+	}
+
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.width, m.height = msg.Width, msg.Height
 
+	case debugTickMsg:
+		// Drop ticks from a previous visit to the page
+		if msg.id != m.tickID {
+			return m, nil
+		}
+		if m.history != nil && !m.detail {
+			// Keep the same call selected as new ones arrive on top and
+			// old ones drop out of the history
+			var seq uint64
+			if m.selected > 0 && m.selected < len(m.calls) {
+				seq = m.calls[m.selected].Seq
+			}
+			m.calls = m.history.Calls()
+			if seq > 0 {
+				m.selected = len(m.calls) - 1
+				for i, c := range m.calls {
+					if c.Seq <= seq {
+						m.selected = i
+						break
+					}
+				}
+			}
+		}
+		return m, m.tick()
+
 	case tea.KeyMsg:
+		if m.detail {
+			switch msg.String() {
+			case "esc", "q", "backspace":
+				m.detail = false
+				m.scrollY = 0
+			case "j", "down":
+				m.scrollY++
+			case "k", "up":
+				if m.scrollY > 0 {
+					m.scrollY--
+				}
+			case "pgdown", " ":
+				m.scrollY += max(1, m.height-4)
+			case "pgup":
+				m.scrollY = max(0, m.scrollY-max(1, m.height-4))
+			case "g":
+				m.scrollY = 0
+			}
+			return m, nil
+		}
 		switch msg.String() {
 		case "esc", "q":
 			return m, func() tea.Msg { return backToDiaryMsg{} }
 		case "j", "down":
-			m.scrollY++
+			if m.selected < len(m.calls)-1 {
+				m.selected++
+			}
 		case "k", "up":
-			if m.scrollY > 0 {
-				m.scrollY--
+			if m.selected > 0 {
+				m.selected--
 			}
 		case "g":
-			m.scrollY = 0
+			m.selected = 0
+		case "x":
+			if m.client != nil {
+				m.explorer = newExplorerModel(m.client)
+				m.explorer.width, m.explorer.height = m.width, m.height
+				m.exploring = true
+				return m, textinput.Blink
+			}
+		case "enter":
+			if len(m.calls) > 0 {
+				m.detail = true
+				m.scrollY = 0
+			}
 		}
 	}
 	return m, nil
 }
 
 func (m debugModel) View() string {
+	if m.exploring {
+		return m.explorer.View()
+	}
+	if m.detail && m.selected < len(m.calls) {
+		return m.viewCall(m.calls[m.selected])
+	}
+
 	var lines []string
 
 	lines = append(lines, styleHeader.Render("Debug Info"), "")
@@ -64,9 +183,104 @@ func (m debugModel) View() string {
 	lines = append(lines, row("Config file", m.configPath))
 	lines = append(lines, "")
 
-	lines = append(lines, styleHelp.Render("[↑/↓] scroll  [g] top  [Esc] back"))
+	lines = append(lines, sectionLabel(fmt.Sprintf("Requests (last %d)", len(m.calls))))
+	if len(m.calls) == 0 {
+		lines = append(lines, styleDimmed.Render("  no requests yet"))
+	}
 
-	return applyScroll(lines, m.scrollY, m.height)
+	// Window the list so the selected call stays visible below the header
+	avail := m.height - len(lines) - 3
+	if avail < 5 {
+		avail = 5
+	}
+	start := 0
+	if m.selected >= avail {
+		start = m.selected - avail + 1
+	}
+	end := min(start+avail, len(m.calls))
+	for i := start; i < end; i++ {
+		c := m.calls[i]
+		line := fmt.Sprintf("  %s  %-6s %s %7s  %s",
+			c.Time.Format("15:04:05"),
+			c.Method,
+			padRight(statusText(c), 3),
+			formatLatency(c.Latency),
+			truncate(c.Path, max(20, m.width-36)),
+		)
+		if i == m.selected {
+			line = styleSelected.Render(line)
+		} else {
+			line = lipgloss.NewStyle().Foreground(statusColor(c)).Render(line)
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, "")
+
+	lines = append(lines, styleHelp.Render("[↑/↓] select  [Enter] inspect  [g] newest  [x] API explorer  [Esc] back"))
+
+	return strings.Join(lines, "\n")
+}
+
+func (m debugModel) viewCall(c api.Call) string {
+	var lines []string
+	title := fmt.Sprintf("%s %s", c.Method, c.Path)
+	lines = append(lines, styleHeader.Render(truncate(title, max(20, m.width-2))))
+	lines = append(lines, row("Time", c.Time.Format("2006-01-02 15:04:05.000")))
+	lines = append(lines, row("Status", lipgloss.NewStyle().Foreground(statusColor(c)).Render(statusText(c))))
+	lines = append(lines, row("Latency", formatLatency(c.Latency)))
+	if c.Err != "" {
+		lines = append(lines, row("Error", styleError.Render(c.Err)))
+	}
+	lines = append(lines, "")
+
+	if c.RequestBody != "" {
+		lines = append(lines, sectionLabel("Request body"))
+		lines = append(lines, strings.Split(prettyJSON(c.RequestBody), "\n")...)
+		lines = append(lines, "")
+	}
+	lines = append(lines, sectionLabel(fmt.Sprintf("Response body (%d bytes)", len(c.ResponseBody))))
+	if c.ResponseBody == "" {
+		lines = append(lines, styleDimmed.Render("  (empty)"))
+	} else {
+		lines = append(lines, strings.Split(prettyJSON(c.ResponseBody), "\n")...)
+	}
+
+	// Keep the help line visible below the scrolled body
+	body := applyScroll(lines, m.scrollY, m.height-1)
+	return body + "\n" + styleHelp.Render("[↑/↓] scroll  [PgUp/PgDn] page  [g] top  [Esc] list")
+}
+
+// prettyJSON indents s if it is JSON and returns it unchanged otherwise.
+func prettyJSON(s string) string {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(s), "  ", "  "); err != nil {
+		return "  " + s
+	}
+	return "  " + buf.String()
+}
+
+func statusText(c api.Call) string {
+	if c.Status == 0 {
+		return "ERR"
+	}
+	return fmt.Sprintf("%d", c.Status)
+}
+
+func statusColor(c api.Call) lipgloss.Color {
+	switch {
+	case c.Status == 0 || c.Status >= 500:
+		return colorError
+	case c.Status >= 400:
+		return colorCarbs
+	}
+	return colorText
+}
+
+func formatLatency(d time.Duration) string {
+	if d < time.Second {
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	}
+	return fmt.Sprintf("%.1fs", d.Seconds())
 }
 
 func applyScroll(lines []string, scrollY, height int) string {
@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"math"
@@ -8,9 +9,13 @@ import (
 	"sync"
 	"time"
 
+	"github.com/charmbracelet/bubbles/spinner"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 	"github.com/koriwi/yazio-cli/internal/api"
+	"github.com/koriwi/yazio-cli/internal/diary"
 	"github.com/koriwi/yazio-cli/internal/models"
 )
 
@@ -26,31 +31,247 @@ type diaryModel struct {
 	selected int // selected entry index for deletion
 	client   *api.Client
 
+	// loadID identifies the load in flight and counts up with every load;
+	// cancel aborts its requests.
+	loadID   int
+	cancel   context.CancelFunc
+	spinner  spinner.Model
+	viewport viewport.Model // scrolls the meal sections below the header
+
+	// shown is the day entries, goals and totals belong to. They stay on
+	// screen while that day reloads, and days maps every day loaded so far
+	// to its last known state so revisiting it doesn't start blank.
+	shown string
+	days  map[string]diaryDay
+
+	// pendingDeletes holds the IDs of entries removed optimistically whose
+	// DELETE hasn't finished, so a reload in between doesn't bring them back.
+	pendingDeletes map[string]bool
+
+	// moving is set by m; the next key picks the meal to move to.
+	moving bool
+
+	// copying shows copyForm, which gets all keys until it is closed.
+	copying  bool
+	copyForm copyForm
+	status   string // result of the last action, cleared by the next key
+
+	// marked holds the IDs of entries selected with space or V, which d, m,
+	// c and % act on instead of the selected entry. anchor is where the next
+	// V range starts.
+	marked map[string]bool
+	anchor string
+
+	// confirm is a bulk action waiting for y or n; scaling asks for the
+	// percentage before a scale is confirmed.
+	confirm    *bulkAction
+	scaling    bool
+	scaleInput textinput.Model
+
+	// undo and redo are the changes made in this session, newest last.
+	undo []change
+	redo []change
+
 	// product cache shared across date navigations
 	cache *sync.Map
 }
 
 type diaryLoadedMsg struct {
+	id      int       // loadID of the load that produced this result
+	date    time.Time // the day that was requested
 	entries []models.DiaryEntry
 	goals   *models.GoalsResponse
 	totals  *models.DailyNutrient
 	err     string
 }
 
+// diaryDay is the loaded state of one day.
+type diaryDay struct {
+	entries []models.DiaryEntry
+	goals   *models.GoalsResponse
+	totals  *models.DailyNutrient
+}
+
 func newDiaryModel(client *api.Client, cache *sync.Map) diaryModel {
+	s := spinner.New()
+	s.Spinner = spinner.MiniDot
+	s.Style = lipgloss.NewStyle().Foreground(colorPrimary)
 	return diaryModel{
-		date:   time.Now(),
-		client: client,
-		cache:  cache,
+		date:           time.Now(),
+		client:         client,
+		cache:          cache,
+		spinner:        s,
+		days:           map[string]diaryDay{},
+		pendingDeletes: map[string]bool{},
+		marked:         map[string]bool{},
 	}
 }
 
 type logoutMsg struct{}
 type editEntryMsg struct{ entry models.DiaryEntry }
 
-func (m diaryModel) loadDiary() tea.Cmd {
-	date := m.date
+// entryDeletedMsg reports the result of deleting entry, which was removed
+// from the list at index before the request was sent.
+type entryDeletedMsg struct {
+	entry models.DiaryEntry
+	date  string
+	index int
+	err   string
+}
+
+// entriesReplacedMsg reports the result of replaceEntries for entries,
+// which were replaced by after.
+type entriesReplacedMsg struct {
+	verb    string
+	entries []models.DiaryEntry
+	after   []models.DiaryEntry
+	date    string
+	err     string
+}
+
+// reload starts loading m.date and supersedes any load still in flight: its
+// requests are cancelled and its result is dropped if it arrives anyway.
+func (m *diaryModel) reload() tea.Cmd {
+	m.stopLoading()
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	m.loadID++
+	m.loading = true
+	m.err = ""
+
+	// Switch to the last known state of the new day, if any
+	if key := m.date.Format(time.DateOnly); key != m.shown {
+		d := m.days[key]
+		m.entries, m.goals, m.totals = d.entries, d.goals, d.totals
+		m.shown = key
+		m.selected = min(m.selected, max(0, len(m.entries)-1))
+	}
+	return tea.Batch(m.loadDiary(ctx, m.loadID), m.spinner.Tick)
+}
+
+// stopLoading cancels the requests of the load in flight, if any.
+func (m *diaryModel) stopLoading() {
+	if m.cancel != nil {
+		m.cancel()
+		m.cancel = nil
+	}
+}
+
+// capturesKeys reports whether a prompt of the page takes every key, so the
+// app's global keys must not act on them.
+func (m diaryModel) capturesKeys() bool {
+	return m.copying || m.moving || m.scaling || m.confirm != nil
+}
+
+// remember stores what is on screen as the last known state of its day.
+func (m *diaryModel) remember() {
+	m.days[m.shown] = diaryDay{entries: m.entries, goals: m.goals, totals: m.totals}
+}
+
+// deleteEntry removes the selected entry right away and deletes it in the
+// background; entryDeletedMsg puts it back if that fails.
+func (m *diaryModel) deleteEntry() tea.Cmd {
+	i := m.selected
+	entry := m.entries[i]
+	m.entries = append(append([]models.DiaryEntry{}, m.entries[:i]...), m.entries[i+1:]...)
+	m.totals = addToTotals(m.totals, entry, -1)
+	m.selected = min(m.selected, max(0, len(m.entries)-1))
+	m.pendingDeletes[entry.ConsumedID] = true
+	m.remember()
+
+	client := m.client
+	date := m.shown
+	return func() tea.Msg {
+		if err := client.DeleteConsumedItem(entry.ConsumedID); err != nil {
+			if errors.Is(err, api.ErrSessionExpired) {
+				return sessionExpiredMsg{}
+			}
+			return entryDeletedMsg{entry: entry, date: date, index: i, err: "delete failed: " + err.Error()}
+		}
+		return entryDeletedMsg{entry: entry, date: date, index: i}
+	}
+}
+
+// replaceEntries shows reqs in place of entries right away and swaps them in
+// the background with diary.Replace. verb names the action in errors.
+func (m *diaryModel) replaceEntries(entries []models.DiaryEntry, reqs []models.AddConsumedRequest, verb string) tea.Cmd {
+	byID := map[string]models.AddConsumedRequest{}
+	for i, e := range entries {
+		byID[e.ConsumedID] = reqs[i]
+		m.pendingDeletes[e.ConsumedID] = true
+	}
+	selected := ""
+	if m.selected < len(m.entries) {
+		selected = m.entries[m.selected].ConsumedID
+	}
+	after := make([]models.DiaryEntry, len(entries))
+	for i, e := range entries {
+		after[i] = replacedEntry(e, reqs[i])
+	}
+	shown := append([]models.DiaryEntry{}, m.entries...)
+	for i, e := range shown {
+		if r, ok := byID[e.ConsumedID]; ok {
+			shown[i] = replacedEntry(e, r)
+			m.totals = addToTotals(addToTotals(m.totals, e, -1), shown[i], 1)
+			if e.ConsumedID == selected {
+				selected = r.ID
+			}
+		}
+	}
+	diary.SortByMeal(shown)
+	m.entries = shown
+	for i, e := range m.entries {
+		if e.ConsumedID == selected {
+			m.selected = i
+		}
+	}
+	m.remember()
+
 	client := m.client
+	date := m.shown
+	return func() tea.Msg {
+		if err := diary.Replace(client, entries, reqs); err != nil {
+			if errors.Is(err, api.ErrSessionExpired) {
+				return sessionExpiredMsg{}
+			}
+			return entriesReplacedMsg{verb: verb, entries: entries, date: date, err: verb + " failed: " + err.Error()}
+		}
+		return entriesReplacedMsg{verb: verb, entries: entries, after: after, date: date}
+	}
+}
+
+// replacedEntry returns e as req logs it, with the nutrients scaled to the
+// new amount.
+func replacedEntry(e models.DiaryEntry, req models.AddConsumedRequest) models.DiaryEntry {
+	n := e
+	n.ConsumedID, n.MealTime = req.ID, req.Daytime
+	if e.Amount > 0 && req.Amount != e.Amount {
+		f := req.Amount / e.Amount
+		n.Kcal = math.Round(e.Kcal*f*10) / 10
+		n.Protein = math.Round(e.Protein*f*10) / 10
+		n.Carbs = math.Round(e.Carbs*f*10) / 10
+		n.Fat = math.Round(e.Fat*f*10) / 10
+	}
+	n.Amount, n.ServingQuantity = req.Amount, req.ServingQuantity
+	return n
+}
+
+// addToTotals returns a copy of totals with sign × the entry's nutrients added.
+func addToTotals(totals *models.DailyNutrient, e models.DiaryEntry, sign float64) *models.DailyNutrient {
+	if totals == nil {
+		return nil
+	}
+	t := *totals
+	t.Energy = math.Max(0, t.Energy+sign*e.Kcal)
+	t.Protein = math.Max(0, t.Protein+sign*e.Protein)
+	t.Carb = math.Max(0, t.Carb+sign*e.Carbs)
+	t.Fat = math.Max(0, t.Fat+sign*e.Fat)
+	return &t
+}
+
+func (m diaryModel) loadDiary(ctx context.Context, id int) tea.Cmd {
+	date := m.date
+	client := m.client.WithContext(ctx)
 	cache := m.cache
 
 	return func() tea.Msg {
@@ -108,17 +329,28 @@ func (m diaryModel) loadDiary() tea.Cmd {
 		}()
 
 		r := <-ch
+		if ctx.Err() != nil {
+			return nil // superseded by a newer load
+		}
 		if r.err != nil {
 			if errors.Is(r.err, api.ErrSessionExpired) {
 				return sessionExpiredMsg{}
 			}
-			return diaryLoadedMsg{err: r.err.Error()}
+			return diaryLoadedMsg{id: id, date: date, err: r.err.Error()}
 		}
 
 		// Resolve product details
-		entries := resolveEntries(r.consumed, client, cache, date)
+		entries, err := diary.ResolveEntries(r.consumed, client, cache)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return sessionExpiredMsg{}
+		}
 
 		return diaryLoadedMsg{
+			id:      id,
+			date:    date,
 			entries: entries,
 			goals:   r.goals,
 			totals:  r.totals,
@@ -126,170 +358,67 @@ func (m diaryModel) loadDiary() tea.Cmd {
 	}
 }
 
-func resolveEntries(consumed *models.ConsumedItemsResponse, client *api.Client, cache *sync.Map, date time.Time) []models.DiaryEntry {
-	if consumed == nil {
-		return nil
-	}
-
-	var entries []models.DiaryEntry
-	var mu sync.Mutex
-	var wg sync.WaitGroup
-
-	for _, cp := range consumed.Products {
-		cp := cp
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			product := fetchProductCached(cp.ProductID, client, cache)
-			entry := buildEntry(cp.ID, cp.ProductID, cp.Daytime, cp, product)
-			mu.Lock()
-			entries = append(entries, entry)
-			mu.Unlock()
-		}()
-	}
-
-	for _, cr := range consumed.RecipePortions {
-		cr := cr
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			product := fetchRecipeCached(cr.RecipeID, client, cache)
-			entry := buildRecipeEntry(cr.ID, cr.RecipeID, cr.Daytime, cr.PortionCount, product)
-			mu.Lock()
-			entries = append(entries, entry)
-			mu.Unlock()
-		}()
-	}
-
-	for _, cp := range consumed.SimpleProducts {
-		cp := cp
-		wg.Add(1)
-		go func() {
-			defer wg.Done()
-			product := fetchProductCached(cp.ProductID, client, cache)
-			entry := buildEntry(cp.ID, cp.ProductID, cp.Daytime, cp, product)
-			mu.Lock()
-			entries = append(entries, entry)
-			mu.Unlock()
-		}()
-	}
-
-	wg.Wait()
-
-	// Sort by meal time order
-	order := map[string]int{"breakfast": 0, "lunch": 1, "dinner": 2, "snack": 3}
-	for i := 0; i < len(entries); i++ {
-		for j := i + 1; j < len(entries); j++ {
-			oi := order[entries[i].MealTime]
-			oj := order[entries[j].MealTime]
-			if oi > oj {
-				entries[i], entries[j] = entries[j], entries[i]
-			}
-		}
-	}
-	return entries
-}
-
-func fetchProductCached(productID string, client *api.Client, cache *sync.Map) *models.ProductResponse {
-	if v, ok := cache.Load(productID); ok {
-		p, _ := v.(*models.ProductResponse)
-		return p
-	}
-	p, err := client.GetProduct(productID)
-	if err != nil {
-		return nil
-	}
-	cache.Store(productID, p)
-	return p
-}
-
-func fetchRecipeCached(recipeID string, client *api.Client, cache *sync.Map) *models.ProductResponse {
-	key := "recipe:" + recipeID
-	if v, ok := cache.Load(key); ok {
-		p, _ := v.(*models.ProductResponse)
-		return p
-	}
-	p, err := client.GetRecipe(recipeID)
-	if err != nil {
-		return nil
-	}
-	cache.Store(key, p)
-	return p
-}
-
-func buildEntry(consumedID, productID, mealTime string, cp models.ConsumedProduct, p *models.ProductResponse) models.DiaryEntry {
-	e := models.DiaryEntry{
-		ConsumedID:      consumedID,
-		ProductID:       productID,
-		MealTime:        mealTime,
-		Amount:          cp.Amount,
-		Serving:         cp.Serving,
-		ServingQuantity: cp.ServingQuantity,
-		Name:            productID, // fallback
-	}
-	amount := cp.Amount
-	if p == nil {
-		return e
-	}
-	e.Name = p.Name
-	// amount is total grams; nutrients are per gram → multiply directly
-	e.Kcal = math.Round(p.Nutrients.EnergyKcal*amount*10) / 10
-	e.Protein = math.Round(p.Nutrients.Protein*amount*10) / 10
-	e.Carbs = math.Round(p.Nutrients.Carb*amount*10) / 10
-	e.Fat = math.Round(p.Nutrients.Fat*amount*10) / 10
-	return e
-}
-
-func buildRecipeEntry(consumedID, recipeID, mealTime string, portions float64, p *models.ProductResponse) models.DiaryEntry {
-	e := models.DiaryEntry{
-		ConsumedID: consumedID,
-		ProductID:  recipeID,
-		MealTime:   mealTime,
-		Amount:     portions,
-		Serving:    "portion",
-		Name:       recipeID,
-	}
-	if p == nil {
-		return e
-	}
-	e.Name = p.Name
-	e.Kcal = math.Round(p.Nutrients.EnergyKcal*portions*10) / 10
-	e.Protein = math.Round(p.Nutrients.Protein*portions*10) / 10
-	e.Carbs = math.Round(p.Nutrients.Carb*portions*10) / 10
-	e.Fat = math.Round(p.Nutrients.Fat*portions*10) / 10
-	return e
+func (m diaryModel) Update(msg tea.Msg) (diaryModel, tea.Cmd) {
+	m, cmd := m.update(msg)
+	m.syncViewport()
+	return m, cmd
 }
 
-func (m diaryModel) Update(msg tea.Msg) (diaryModel, tea.Cmd) {
+func (m diaryModel) update(msg tea.Msg) (diaryModel, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.width, m.height = msg.Width, msg.Height
 
 	case tea.KeyMsg:
-		if m.loading {
-			break
+		m.status = ""
+		if m.confirm != nil {
+			return m.updateConfirm(msg)
+		}
+		if m.copying {
+			return m.updateCopy(msg)
 		}
+		if m.scaling {
+			return m.updateScale(msg)
+		}
+		if m.moving {
+			m.moving = false
+			if i := strings.Index("1234", msg.String()); i >= 0 && len(msg.String()) == 1 {
+				meal := models.MealTimes[i]
+				if marked := m.markedEntries(); len(marked) > 0 {
+					return m.confirmMove(marked, meal), nil
+				}
+				if e := m.entries[m.selected]; e.MealTime != meal {
+					return m, m.replaceEntries([]models.DiaryEntry{e}, diary.CopyRequests([]models.DiaryEntry{e}, []time.Time{m.date}, meal), "move")
+				}
+			}
+			return m, nil
+		}
+		// Day navigation stays live while loading; each new load supersedes
+		// the previous one.
 		switch msg.String() {
 		case "left", "h":
 			m.date = m.date.AddDate(0, 0, -1)
-			m.loading = true
-			m.err = ""
 			m.selected = 0
-			return m, m.loadDiary()
+			m.clearMarks()
+			return m, m.reload()
 		case "right", "l":
 			if m.date.Before(time.Now().Truncate(24 * time.Hour)) {
 				m.date = m.date.AddDate(0, 0, 1)
-				m.loading = true
-				m.err = ""
 				m.selected = 0
-				return m, m.loadDiary()
+				m.clearMarks()
+				return m, m.reload()
 			}
 		case "t":
 			m.date = time.Now()
-			m.loading = true
-			m.err = ""
 			m.selected = 0
-			return m, m.loadDiary()
+			m.clearMarks()
+			return m, m.reload()
+		case "r":
+			return m, m.reload()
+		case "L":
+			return m, func() tea.Msg { return logoutMsg{} }
+		}
+		switch msg.String() {
 		case "j", "down":
 			if m.selected < len(m.entries)-1 {
 				m.selected++
@@ -305,36 +434,157 @@ func (m diaryModel) Update(msg tea.Msg) (diaryModel, tea.Cmd) {
 					return m, func() tea.Msg { return editEntryMsg{entry: entry} }
 				}
 			}
+		case " ", "space":
+			m.toggleMark()
+		case "V":
+			m.markRange()
+		case "esc":
+			m.clearMarks()
 		case "d":
+			if marked := m.markedEntries(); len(marked) > 0 {
+				return m.confirmDelete(marked), nil
+			}
 			if len(m.entries) > 0 {
-				entry := m.entries[m.selected]
-				if entry.ConsumedID != "" {
-					m.loading = true
-					client := m.client
-					id := entry.ConsumedID
-					date := m.date
-					cache := m.cache
-					return m, func() tea.Msg {
-						if err := client.DeleteConsumedItem(id); err != nil {
-							if errors.Is(err, api.ErrSessionExpired) {
-								return sessionExpiredMsg{}
-							}
-							return diaryLoadedMsg{err: "delete failed: " + err.Error()}
-						}
-						tmp := diaryModel{date: date, client: client, cache: cache}
-						return tmp.loadDiary()()
-					}
+				if m.entries[m.selected].ConsumedID != "" {
+					return m, m.deleteEntry()
 				}
 			}
-		case "r":
-			m.loading = true
-			m.err = ""
-			return m, m.loadDiary()
-		case "L":
-			return m, func() tea.Msg { return logoutMsg{} }
+		case "m":
+			if len(m.targets()) > 0 {
+				m.moving = true
+			}
+		case "u":
+			return m, m.revert(false)
+		case "ctrl+r":
+			return m, m.revert(true)
+		case "%":
+			m = m.startScale()
+			if m.scaling {
+				return m, textinput.Blink
+			}
+		case "c":
+			if len(m.entries) > 0 {
+				scope := m.copyForm.scope
+				m.copyForm = newCopyForm()
+				m.copyForm.scope = scope
+				m.copying = true
+				return m, textinput.Blink
+			}
+		}
+
+	case entriesDeletedMsg:
+		for _, e := range msg.entries {
+			delete(m.pendingDeletes, e.ConsumedID)
+		}
+		if msg.err != "" {
+			m.err = msg.err
+		} else {
+			m.record(change{verb: "delete", date: msg.date, before: msg.entries})
+		}
+		if msg.date != m.shown {
+			delete(m.days, msg.date)
+			return m, nil
+		}
+		return m, m.reload()
+
+	case revertedMsg:
+		if msg.err != "" {
+			// Nothing changed, so the change can be tried again
+			if msg.redo {
+				m.redo = append(m.redo, msg.c)
+			} else {
+				m.undo = append(m.undo, msg.c)
+			}
+			m.status = ""
+			m.err = msg.err
+		} else if msg.redo {
+			m.undo = append(m.undo, msg.inverse)
+			m.status = "Redid " + msg.c.describe()
+		} else {
+			m.redo = append(m.redo, msg.inverse)
+			m.status = "Undid " + msg.c.describe()
+		}
+		if msg.err == "" && msg.c.date != m.shown {
+			m.status += " on " + msg.c.date
+		}
+		if msg.c.date != m.shown {
+			delete(m.days, msg.c.date)
+			return m, nil
+		}
+		return m, m.reload()
+
+	case entriesReplacedMsg:
+		for _, e := range msg.entries {
+			delete(m.pendingDeletes, e.ConsumedID)
+		}
+		if msg.err != "" {
+			m.err = msg.err
+		} else {
+			m.record(change{verb: msg.verb, date: msg.date, before: msg.entries, after: msg.after})
+		}
+		// Either way the server has the final state now
+		if msg.date != m.shown {
+			delete(m.days, msg.date)
+			return m, nil
+		}
+		return m, m.reload()
+
+	case copiedMsg:
+		// The copies' days changed; forget what was loaded for them
+		reload := false
+		for _, d := range msg.dates {
+			delete(m.days, d)
+			reload = reload || d == m.shown
+		}
+		if msg.err != "" {
+			m.status = ""
+			m.err = msg.err
+		} else if len(msg.dates) == 1 {
+			m.status = fmt.Sprintf("Copied %d item(s) to %s", msg.n, msg.dates[0])
+		} else {
+			m.status = fmt.Sprintf("Copied %d item(s) to %s – %s", msg.n, msg.dates[0], msg.dates[len(msg.dates)-1])
+		}
+		if reload {
+			return m, m.reload()
 		}
 
+	case spinner.TickMsg:
+		if !m.loading {
+			return m, nil // let the tick chain end
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+
+	case entryDeletedMsg:
+		delete(m.pendingDeletes, msg.entry.ConsumedID)
+		if msg.err == "" {
+			m.record(change{verb: "delete", date: msg.date, before: []models.DiaryEntry{msg.entry}})
+		}
+		if msg.err != "" {
+			// Put the entry back where it was
+			if msg.date == m.shown {
+				i := min(msg.index, len(m.entries))
+				m.entries = append(m.entries[:i], append([]models.DiaryEntry{msg.entry}, m.entries[i:]...)...)
+				m.totals = addToTotals(m.totals, msg.entry, 1)
+				m.remember()
+			} else {
+				delete(m.days, msg.date)
+			}
+			m.err = msg.err
+			return m, nil
+		}
+		if msg.date != m.shown {
+			return m, nil
+		}
+		return m, m.reload()
+
 	case diaryLoadedMsg:
+		// Drop results for a day the user has already navigated away from
+		if msg.id != m.loadID || !msg.date.Equal(m.date) {
+			return m, nil
+		}
+		m.stopLoading()
 		m.loading = false
 		if msg.err != "" {
 			m.err = msg.err
@@ -342,6 +592,15 @@ func (m diaryModel) Update(msg tea.Msg) (diaryModel, tea.Cmd) {
 			m.entries = msg.entries
 			m.goals = msg.goals
 			m.totals = msg.totals
+			// Hide entries whose delete is still in flight
+			for i := len(m.entries) - 1; i >= 0; i-- {
+				if e := m.entries[i]; m.pendingDeletes[e.ConsumedID] {
+					m.entries = append(m.entries[:i], m.entries[i+1:]...)
+					m.totals = addToTotals(m.totals, e, -1)
+				}
+			}
+			m.remember()
+			m.pruneMarks()
 			if m.selected >= len(m.entries) {
 				m.selected = max(0, len(m.entries)-1)
 			}
@@ -351,10 +610,12 @@ func (m diaryModel) Update(msg tea.Msg) (diaryModel, tea.Cmd) {
 }
 
 func (m diaryModel) View() string {
-	if m.loading {
-		return "\n  Loading...\n"
-	}
+	return m.headerView() + m.viewport.View() + "\n" + m.helpView()
+}
 
+// headerView renders the date navigation, progress bars and errors, which
+// stay pinned above the scrolling entry list.
+func (m diaryModel) headerView() string {
 	var sb strings.Builder
 
 	// Date navigation header
@@ -366,8 +627,16 @@ func (m diaryModel) View() string {
 	}
 	nav := fmt.Sprintf("← %s %s", dateStr, rightArrow)
 	sb.WriteString(styleDateNav.Render(nav))
+	if m.loading {
+		sb.WriteString(" " + m.spinner.View())
+	}
 	sb.WriteString("\n\n")
 
+	if m.copying {
+		sb.WriteString(m.copyView())
+	}
+	sb.WriteString(m.bulkView())
+
 	// Progress bars
 	if m.totals != nil || m.goals != nil {
 		sb.WriteString(m.renderProgressBars())
@@ -378,6 +647,73 @@ func (m diaryModel) View() string {
 	if m.err != "" {
 		sb.WriteString(styleError.Render("Error: "+m.err) + "\n")
 	}
+	if m.status != "" {
+		sb.WriteString(lipgloss.NewStyle().Foreground(colorSuccess).Render(m.status) + "\n")
+	}
+	if n := countUnresolved(m.entries); n > 0 {
+		sb.WriteString(styleError.Render(fmt.Sprintf("%d item(s) could not be loaded — [r] to retry", n)) + "\n")
+	}
+	return sb.String()
+}
+
+// helpView renders the key help below the entry list, wrapped to the
+// terminal width so its height can be measured.
+func (m diaryModel) helpView() string {
+	helpItems := []string{
+		"[←/→] date",
+		"[↑/↓] select",
+		"[a] add",
+		"[e] edit",
+		"[space/V] select",
+		"[d] delete",
+		"[m] move",
+		"[c] copy",
+		"[%] scale",
+		"[u/ctrl+r] undo/redo",
+		"[t] today",
+		"[w] week",
+		"[M] month",
+		"[T] trends",
+		"[r] refresh",
+		"[?] debug",
+		"[L] logout",
+		"[q] quit",
+	}
+	if n := len(m.markedEntries()); n > 0 {
+		helpItems = []string{fmt.Sprintf("%d selected", n), "[space] toggle", "[V] range", "[d] delete", "[m] move",
+			"[c] copy", "[%] scale", "[esc] clear"}
+	}
+	if m.copying {
+		helpItems = []string{"[enter] copy", "[tab] entry/meal/day", "[↑/↓] meal", "[esc] cancel"}
+		if len(m.markedEntries()) > 0 {
+			helpItems = []string{"[enter] copy", "[↑/↓] meal", "[esc] cancel"}
+		}
+	}
+	if m.scaling {
+		helpItems = []string{"[enter] scale", "[esc] cancel"}
+	}
+	if m.confirm != nil {
+		helpItems = []string{"[y/enter] confirm", "[n/esc] cancel"}
+	}
+	if m.moving {
+		helpItems = []string{"move to:"}
+		for i, mt := range models.MealTimes {
+			helpItems = append(helpItems, fmt.Sprintf("[%d] %s", i+1, models.MealTimeLabel(mt)))
+		}
+		helpItems = append(helpItems, "[esc] cancel")
+	}
+	help := styleHelp
+	if m.width > 0 {
+		help = help.Width(m.width)
+	}
+	return help.Render(strings.Join(helpItems, "  "))
+}
+
+// entryLines renders the meal sections line by line and returns the index of
+// the selected entry's line (-1 when nothing is selected).
+func (m diaryModel) entryLines() ([]string, int) {
+	var lines []string
+	selLine := -1
 
 	// Meal sections
 	mealTimes := []string{"breakfast", "lunch", "dinner", "snack"}
@@ -402,26 +738,34 @@ func (m diaryModel) View() string {
 			mealKcal += e.Kcal
 		}
 
+		// styleMealHeader has a top margin, so the header is two lines
 		header := styleMealHeader.Render(fmt.Sprintf("%s  %s", label, styleKcal.Render(fmt.Sprintf("%.0f kcal", mealKcal))))
-		sb.WriteString(header + "\n")
+		lines = append(lines, strings.Split(header, "\n")...)
 
 		if len(items) == 0 {
-			sb.WriteString(styleDimmed.Render("  —") + "\n")
+			lines = append(lines, styleDimmed.Render("  —"))
 		} else {
-			for idx, e := range items {
+			for _, e := range items {
 				// Find global index
 				globalIdx := findGlobalIndex(m.entries, e)
 				isSelected := globalIdx == m.selected
 
 				name := truncate(e.Name, availW/2)
-				serving := formatServing(e.Amount, e.Serving, e.ServingQuantity)
+				if e.Err != "" {
+					name = truncate("⚠ failed to load: "+e.Err, availW/2)
+				}
+				serving := models.FormatServing(e.Amount, e.Serving, e.ServingQuantity)
 				kcalStr := fmt.Sprintf("%.0f kcal", e.Kcal)
 				macros := fmt.Sprintf("P:%.1fg C:%.1fg F:%.1fg", e.Protein, e.Carbs, e.Fat)
 
 				nameW := availW / 2
 				servW := 12
 
-				line := fmt.Sprintf("  %s %s %s  %s",
+				marker := "  "
+				if m.marked[e.ConsumedID] {
+					marker = "● "
+				}
+				line := fmt.Sprintf("%s%s %s %s  %s", marker,
 					padRight(name, nameW),
 					padRight(truncate(serving, servW), servW),
 					padRight(kcalStr, 10),
@@ -430,32 +774,47 @@ func (m diaryModel) View() string {
 
 				if isSelected {
 					line = styleSelected.Render(line)
+					selLine = len(lines)
+				} else if e.Err != "" {
+					line = styleError.UnsetBold().Render(line)
 				} else {
 					line = styleItemName.Render(line)
 				}
-				sb.WriteString(line + "\n")
-				_ = idx
+				lines = append(lines, line)
 			}
 		}
-		sb.WriteString("\n")
+		lines = append(lines, "")
 	}
+	return lines, selLine
+}
 
-	// Help
-	helpItems := []string{
-		"[←/→] date",
-		"[↑/↓] select",
-		"[a] add",
-		"[e] edit",
-		"[d] delete",
-		"[t] today",
-		"[r] refresh",
-		"[?] debug",
-		"[L] logout",
-		"[q] quit",
+// syncViewport refreshes the entry list viewport: it fills the space between
+// the header and the help, and scrolls just enough to show the selection.
+func (m *diaryModel) syncViewport() {
+	lines, selLine := m.entryLines()
+	height := len(lines)
+	if m.height > 0 {
+		height = max(3, m.height-lipgloss.Height(m.headerView())-lipgloss.Height(m.helpView()))
+	}
+	m.viewport.Width = m.width
+	m.viewport.Height = height
+	m.viewport.SetContent(strings.Join(lines, "\n"))
+	m.viewport.SetYOffset(m.viewport.YOffset) // re-clamp after a resize
+
+	if selLine < 0 {
+		return
+	}
+	top := selLine
+	if top >= 2 && lines[top-2] == "" {
+		// First entry of a meal: bring the meal header into view too
+		top--
+	}
+	switch {
+	case top < m.viewport.YOffset:
+		m.viewport.SetYOffset(top)
+	case selLine >= m.viewport.YOffset+height:
+		m.viewport.SetYOffset(selLine - height + 1)
 	}
-	sb.WriteString(styleHelp.Render(strings.Join(helpItems, "  ")))
-
-	return sb.String()
 }
 
 func (m diaryModel) renderProgressBars() string {
@@ -507,6 +866,16 @@ func renderBar(label string, current, goal float64, unit string, color lipgloss.
 	return fmt.Sprintf("  %s  [%s]  %s\n", label, bar, nums)
 }
 
+func countUnresolved(entries []models.DiaryEntry) int {
+	n := 0
+	for _, e := range entries {
+		if e.Err != "" {
+			n++
+		}
+	}
+	return n
+}
+
 func filterByMeal(entries []models.DiaryEntry, mealTime string) []models.DiaryEntry {
 	var out []models.DiaryEntry
 	for _, e := range entries {
@@ -538,24 +907,6 @@ func formatDate(t time.Time) string {
 	return t.Format("Mon, Jan 2")
 }
 
-func formatServing(amountGrams float64, serving string, qty float64) string {
-	switch serving {
-	case "gram", "g", "":
-		return fmt.Sprintf("%.0fg", amountGrams)
-	case "ml":
-		return fmt.Sprintf("%.0fml", amountGrams)
-	default:
-		// Show serving count + label (e.g. "2 cookies", "1 package")
-		if qty <= 0 {
-			qty = 1
-		}
-		if qty == 1 {
-			return fmt.Sprintf("1 %s", serving)
-		}
-		return fmt.Sprintf("%.0f %ss", qty, serving)
-	}
-}
-
 func max(a, b int) int {
 	if a > b {
 		return a
@@ -0,0 +1,48 @@
+package tui
+
+import (
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// trackCancel replaces the cancel func of the load in flight with one that
+// counts its calls.
+func trackCancel(m *diaryModel) *int {
+	calls := new(int)
+	m.cancel = func() { *calls++ }
+	return calls
+}
+
+func TestFinishedLoadIsCancelled(t *testing.T) {
+	m := loadedDiary(testEntries("a"))
+	m.reload()
+	calls := trackCancel(&m)
+	m, _ = m.Update(diaryLoadedMsg{id: m.loadID, date: m.date, entries: testEntries("a", "b")})
+	if *calls != 1 || m.cancel != nil {
+		t.Errorf("cancel called %d times, cancel = %v after the load finished", *calls, m.cancel != nil)
+	}
+
+	// A superseded load is cancelled by the next one
+	m.reload()
+	calls = trackCancel(&m)
+	m.reload()
+	if *calls != 1 {
+		t.Errorf("reload cancelled the previous load %d times, want 1", *calls)
+	}
+}
+
+func TestLogoutCancelsLoad(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	for _, msg := range []tea.Msg{logoutMsg{}, sessionExpiredMsg{}} {
+		a := New(false, "", "", "")
+		a.diary = loadedDiary(testEntries("a"))
+		a.diary.reload()
+		calls := trackCancel(&a.diary)
+		a.Update(msg)
+		if *calls != 1 {
+			t.Errorf("%T: cancel called %d times, want 1", msg, *calls)
+		}
+	}
+}
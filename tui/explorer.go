@@ -0,0 +1,314 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/koriwi/yazio-cli/internal/api"
+	"github.com/koriwi/yazio-cli/internal/auth"
+)
+
+// savedRequestsFile holds explorer requests saved with ctrl+w, next to config.json.
+const savedRequestsFile = "saved-requests.json"
+
+type savedRequest struct {
+	Method string `json:"method"`
+	Path   string `json:"path"`
+	Body   string `json:"body,omitempty"`
+}
+
+func (r savedRequest) label() string {
+	return r.Method + " " + r.Path
+}
+
+type explorerField int
+
+const (
+	fieldMethod explorerField = iota
+	fieldPath
+	fieldBody
+	fieldSaved
+	explorerFields
+)
+
+// explorerModel is the raw API explorer on the debug page: type a method, path
+// and JSON body and send it with the current access token.
+type explorerModel struct {
+	client *api.Client
+	method textinput.Model
+	path   textinput.Model
+	body   textarea.Model
+	focus  explorerField
+
+	saved    []savedRequest
+	savedIdx int
+
+	sending  bool
+	status   int
+	latency  time.Duration
+	response string
+	err      string
+	scrollY  int // response scroll offset
+	width    int
+	height   int
+}
+
+type rawResponseMsg struct {
+	status  int
+	latency time.Duration
+	body    string
+	err     string
+}
+
+func newExplorerModel(client *api.Client) explorerModel {
+	method := textinput.New()
+	method.Placeholder = "GET"
+	method.CharLimit = 7
+	method.Width = 8
+	method.Prompt = ""
+	method.SetValue("GET")
+
+	path := textinput.New()
+	path.Placeholder = "/v15/user"
+	path.CharLimit = 256
+	path.Width = 60
+	path.Prompt = ""
+	path.SetValue("/v15/user")
+
+	body := textarea.New()
+	body.Placeholder = `{"key": "value"}`
+	body.ShowLineNumbers = false
+	body.SetWidth(60)
+	body.SetHeight(4)
+
+	m := explorerModel{client: client, method: method, path: path, body: body}
+	auth.LoadJSON(savedRequestsFile, &m.saved)
+	m.focusField(fieldPath)
+	return m
+}
+
+func (m *explorerModel) focusField(f explorerField) {
+	m.focus = f
+	m.method.Blur()
+	m.path.Blur()
+	m.body.Blur()
+	switch f {
+	case fieldMethod:
+		m.method.Focus()
+	case fieldPath:
+		m.path.Focus()
+	case fieldBody:
+		m.body.Focus()
+	}
+}
+
+func (m explorerModel) current() savedRequest {
+	return savedRequest{
+		Method: strings.ToUpper(strings.TrimSpace(m.method.Value())),
+		Path:   strings.TrimSpace(m.path.Value()),
+		Body:   strings.TrimSpace(m.body.Value()),
+	}
+}
+
+func (m explorerModel) send() tea.Cmd {
+	client := m.client
+	req := m.current()
+	return func() tea.Msg {
+		start := time.Now()
+		body, status, err := client.Raw(req.Method, req.Path, req.Body)
+		msg := rawResponseMsg{status: status, latency: time.Since(start), body: body}
+		if err != nil {
+			msg.err = err.Error()
+		}
+		return msg
+	}
+}
+
+func (m explorerModel) Update(msg tea.Msg) (explorerModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case rawResponseMsg:
+		m.sending = false
+		m.status = msg.status
+		m.latency = msg.latency
+		m.response = msg.body
+		m.err = msg.err
+		m.scrollY = 0
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc":
+			return m, func() tea.Msg { return closeExplorerMsg{} }
+		case "tab":
+			m.focusField((m.focus + 1) % explorerFields)
+			return m, nil
+		case "shift+tab":
+			m.focusField((m.focus + explorerFields - 1) % explorerFields)
+			return m, nil
+		case "ctrl+g":
+			return m.trySend()
+		case "ctrl+w":
+			m.save()
+			return m, nil
+		case "pgdown":
+			m.scrollY += max(1, m.responseHeight()-1)
+			return m, nil
+		case "pgup":
+			m.scrollY = max(0, m.scrollY-max(1, m.responseHeight()-1))
+			return m, nil
+		}
+
+		switch m.focus {
+		case fieldMethod, fieldPath:
+			if msg.String() == "enter" {
+				return m.trySend()
+			}
+		case fieldSaved:
+			switch msg.String() {
+			case "j", "down":
+				if m.savedIdx < len(m.saved)-1 {
+					m.savedIdx++
+				}
+			case "k", "up":
+				if m.savedIdx > 0 {
+					m.savedIdx--
+				}
+			case "enter":
+				if m.savedIdx < len(m.saved) {
+					r := m.saved[m.savedIdx]
+					m.method.SetValue(r.Method)
+					m.path.SetValue(r.Path)
+					m.body.SetValue(r.Body)
+					m.focusField(fieldPath)
+				}
+			case "d", "delete":
+				if m.savedIdx < len(m.saved) {
+					m.saved = append(m.saved[:m.savedIdx], m.saved[m.savedIdx+1:]...)
+					m.savedIdx = max(0, min(m.savedIdx, len(m.saved)-1))
+					m.persist()
+				}
+			}
+			return m, nil
+		}
+	}
+
+	var cmds []tea.Cmd
+	var cmd tea.Cmd
+	m.method, cmd = m.method.Update(msg)
+	cmds = append(cmds, cmd)
+	m.path, cmd = m.path.Update(msg)
+	cmds = append(cmds, cmd)
+	m.body, cmd = m.body.Update(msg)
+	cmds = append(cmds, cmd)
+	return m, tea.Batch(cmds...)
+}
+
+func (m explorerModel) trySend() (explorerModel, tea.Cmd) {
+	req := m.current()
+	if m.sending {
+		return m, nil
+	}
+	if req.Method == "" || !strings.HasPrefix(req.Path, "/") {
+		m.err = "method and a path starting with / are required"
+		return m, nil
+	}
+	m.sending = true
+	m.err = ""
+	return m, m.send()
+}
+
+// save stores the current request, replacing an identical method+path.
+func (m *explorerModel) save() {
+	req := m.current()
+	if req.Method == "" || req.Path == "" {
+		return
+	}
+	for i, r := range m.saved {
+		if r.Method == req.Method && r.Path == req.Path {
+			m.saved[i] = req
+			m.savedIdx = i
+			m.persist()
+			return
+		}
+	}
+	m.saved = append(m.saved, req)
+	m.savedIdx = len(m.saved) - 1
+	m.persist()
+}
+
+func (m *explorerModel) persist() {
+	if err := auth.SaveJSON(savedRequestsFile, m.saved); err != nil {
+		m.err = "save failed: " + err.Error()
+	}
+}
+
+// responseHeight is the number of lines left for the response body.
+func (m explorerModel) responseHeight() int {
+	used := 16 + min(len(m.saved), 5)
+	return max(5, m.height-used)
+}
+
+func (m explorerModel) View() string {
+	var lines []string
+	lines = append(lines, styleHeader.Render("API Explorer"))
+
+	label := func(f explorerField, s string) string {
+		if m.focus == f {
+			return styleSelected.Render(s)
+		}
+		return styleDimmed.Render(s)
+	}
+	lines = append(lines, fmt.Sprintf("  %s %s  %s %s",
+		label(fieldMethod, "Method"), m.method.View(),
+		label(fieldPath, "Path"), m.path.View()))
+	lines = append(lines, "  "+label(fieldBody, "Body"))
+	for _, l := range strings.Split(m.body.View(), "\n") {
+		lines = append(lines, "  "+l)
+	}
+	lines = append(lines, "")
+
+	lines = append(lines, sectionLabel(fmt.Sprintf("Saved (%d)", len(m.saved))))
+	if len(m.saved) == 0 {
+		lines = append(lines, styleDimmed.Render("  [ctrl+w] saves the current request"))
+	}
+	start := 0
+	if m.savedIdx >= 5 {
+		start = m.savedIdx - 4
+	}
+	for i := start; i < min(start+5, len(m.saved)); i++ {
+		line := "  " + truncate(m.saved[i].label(), max(20, m.width-4))
+		if m.focus == fieldSaved && i == m.savedIdx {
+			line = styleSelected.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, "")
+
+	switch {
+	case m.sending:
+		lines = append(lines, sectionLabel("Response"), styleDimmed.Render("  Sending..."))
+	case m.err != "":
+		lines = append(lines, sectionLabel("Response"), styleError.Render("  "+m.err))
+	case m.status != 0:
+		c := api.Call{Status: m.status}
+		status := lipgloss.NewStyle().Foreground(statusColor(c)).Render(statusText(c))
+		lines = append(lines, sectionLabel(fmt.Sprintf("Response %s · %s · %d bytes", status, formatLatency(m.latency), len(m.response))))
+		body := strings.Split(prettyJSON(m.response), "\n")
+		lines = append(lines, applyScroll(body, m.scrollY, m.responseHeight()+1))
+	}
+	lines = append(lines, "")
+
+	help := "[Tab] next field  [Enter/ctrl+g] send  [ctrl+w] save  [PgUp/PgDn] scroll  [Esc] back"
+	if m.focus == fieldSaved {
+		help = "[↑/↓] select  [Enter] load  [d] delete  [Tab] next field  [Esc] back"
+	}
+	lines = append(lines, styleHelp.Render(help))
+	return strings.Join(lines, "\n")
+}
+
+type closeExplorerMsg struct{}
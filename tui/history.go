@@ -0,0 +1,98 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/koriwi/yazio-cli/internal/api"
+	"github.com/koriwi/yazio-cli/internal/diary"
+	"github.com/koriwi/yazio-cli/internal/models"
+)
+
+// change is a finished diary mutation, kept so u and ctrl+r can undo and
+// redo it: the entries it removed from date and the ones it logged there.
+type change struct {
+	verb   string // "delete", "add", "edit", "move" or "scale"
+	date   string
+	before []models.DiaryEntry
+	after  []models.DiaryEntry
+}
+
+// maxHistory is how many changes can be undone.
+const maxHistory = 50
+
+// revertedMsg reports the result of undoing or redoing a change. inverse
+// undoes what was just done; on error, c goes back where it came from.
+type revertedMsg struct {
+	c       change
+	inverse change
+	redo    bool
+	err     string
+}
+
+// record adds a change to the undo history, which ends what can be redone.
+func (m *diaryModel) record(c change) {
+	m.undo = append(m.undo, c)
+	if len(m.undo) > maxHistory {
+		m.undo = m.undo[len(m.undo)-maxHistory:]
+	}
+	m.redo = nil
+}
+
+// revert undoes the last change, or redoes the last undone one. The API
+// can't restore deleted entries, so they're logged again with new IDs before
+// the entries the change logged are deleted.
+func (m *diaryModel) revert(redo bool) tea.Cmd {
+	stack := &m.undo
+	if redo {
+		stack = &m.redo
+	}
+	if len(*stack) == 0 {
+		if redo {
+			m.status = "Nothing to redo"
+		} else {
+			m.status = "Nothing to undo"
+		}
+		return nil
+	}
+	c := (*stack)[len(*stack)-1]
+	*stack = (*stack)[:len(*stack)-1]
+
+	date, _ := time.Parse(time.DateOnly, c.date)
+	reqs := diary.CopyRequests(c.before, []time.Time{date}, "")
+	restored := make([]models.DiaryEntry, len(reqs))
+	for i, r := range reqs {
+		restored[i] = replacedEntry(c.before[i], r)
+	}
+	inverse := change{verb: c.verb, date: c.date, before: c.after, after: restored}
+
+	action, doing := "undo", "Undoing "
+	if redo {
+		action, doing = "redo", "Redoing "
+	}
+	m.status = doing + c.describe() + "…"
+	client := m.client
+	return func() tea.Msg {
+		if err := diary.Replace(client, c.after, reqs); err != nil {
+			if errors.Is(err, api.ErrSessionExpired) {
+				return sessionExpiredMsg{}
+			}
+			return revertedMsg{c: c, redo: redo, err: action + " failed: " + err.Error()}
+		}
+		return revertedMsg{c: c, inverse: inverse, redo: redo}
+	}
+}
+
+// describe names a change for the status line, e.g. "delete of Banana".
+func (c change) describe() string {
+	entries := c.before
+	if len(c.after) > len(entries) {
+		entries = c.after
+	}
+	if len(entries) == 1 {
+		return c.verb + " of " + entries[0].Name
+	}
+	return fmt.Sprintf("%s of %d items", c.verb, len(entries))
+}
@@ -0,0 +1,308 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/koriwi/yazio-cli/internal/api"
+	"github.com/koriwi/yazio-cli/internal/models"
+	"golang.org/x/sync/errgroup"
+)
+
+// streakLookback is how many days before the month are loaded as well, so a
+// streak that started last month is counted in full.
+const streakLookback = 60
+
+// monthModel shows a calendar of one month with every day tinted by how
+// close its calories were to the goal.
+type monthModel struct {
+	client   *api.Client
+	month    time.Time // first day of the month shown
+	selected time.Time
+	days     map[string]models.DailyNutrient // by date, includes the lookback
+	goals    *models.GoalsResponse
+	loading  bool
+	err      string
+	width    int
+	height   int
+}
+
+type monthLoadedMsg struct {
+	month time.Time
+	days  []models.DailyNutrient
+	goals *models.GoalsResponse
+	err   string
+}
+
+func newMonthModel(client *api.Client, date time.Time) monthModel {
+	return monthModel{
+		client:   client,
+		month:    monthStart(date),
+		selected: dayStart(date),
+		loading:  true,
+	}
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// addMonths moves day by n months. Days the target month doesn't have, like
+// the 31st, become its last day instead of spilling into the next month.
+func addMonths(day time.Time, n int) time.Time {
+	target := monthStart(day).AddDate(0, n, 0)
+	last := target.AddDate(0, 1, -1).Day()
+	return time.Date(target.Year(), target.Month(), min(day.Day(), last), 0, 0, 0, 0, day.Location())
+}
+
+func (m monthModel) load() tea.Cmd {
+	client := m.client
+	month := m.month
+	start := month.AddDate(0, 0, -streakLookback)
+	end := month.AddDate(0, 1, -1)
+	if today := dayStart(time.Now()); end.After(today) {
+		end = today
+	}
+
+	return func() tea.Msg {
+		var days []models.DailyNutrient
+		var goals *models.GoalsResponse
+		var g errgroup.Group
+		g.Go(func() (err error) {
+			days, err = client.GetDailyNutrientsRange(start, end)
+			return err
+		})
+		g.Go(func() (err error) {
+			goals, err = client.GetGoals(end)
+			return err
+		})
+		if err := g.Wait(); err != nil {
+			if errors.Is(err, api.ErrSessionExpired) {
+				return sessionExpiredMsg{}
+			}
+			return monthLoadedMsg{month: month, err: err.Error()}
+		}
+		return monthLoadedMsg{month: month, days: days, goals: goals}
+	}
+}
+
+// selectDay moves the selection to day, loading another month if needed.
+// Days in the future can't be selected.
+func (m monthModel) selectDay(day time.Time) (monthModel, tea.Cmd) {
+	if day.After(time.Now()) {
+		return m, nil
+	}
+	m.selected = day
+	if ms := monthStart(day); !ms.Equal(m.month) {
+		m.month = ms
+		m.days = nil
+		m.loading = true
+		m.err = ""
+		return m, m.load()
+	}
+	return m, nil
+}
+
+func (m monthModel) Update(msg tea.Msg) (monthModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+
+	case monthLoadedMsg:
+		if !msg.month.Equal(m.month) {
+			return m, nil // a month we already navigated away from
+		}
+		m.loading = false
+		if msg.err != "" {
+			m.err = msg.err
+			break
+		}
+		m.goals = msg.goals
+		m.days = map[string]models.DailyNutrient{}
+		for _, d := range msg.days {
+			m.days[d.Date] = d
+		}
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc", "q", "M":
+			return m, func() tea.Msg { return backToDiaryMsg{} }
+		case "left", "h":
+			return m.selectDay(m.selected.AddDate(0, 0, -1))
+		case "right", "l":
+			return m.selectDay(m.selected.AddDate(0, 0, 1))
+		case "up", "k":
+			return m.selectDay(m.selected.AddDate(0, 0, -7))
+		case "down", "j":
+			return m.selectDay(m.selected.AddDate(0, 0, 7))
+		case "[":
+			return m.selectDay(addMonths(m.selected, -1))
+		case "]":
+			next := addMonths(m.selected, 1)
+			if next.After(time.Now()) {
+				next = dayStart(time.Now())
+			}
+			return m.selectDay(next)
+		case "t":
+			return m.selectDay(dayStart(time.Now()))
+		case "enter":
+			date := m.selected
+			return m, func() tea.Msg { return openDayMsg{date: date} }
+		}
+	}
+	return m, nil
+}
+
+func (m monthModel) day(t time.Time) models.DailyNutrient {
+	return m.days[t.Format(time.DateOnly)]
+}
+
+// logged reports whether anything with calories was logged on t.
+func (m monthModel) logged(t time.Time) bool {
+	return m.day(t).Energy > 0
+}
+
+func (m monthModel) energyGoal(t time.Time) float64 {
+	if g := m.day(t).EnergyGoal; g > 0 {
+		return g
+	}
+	if m.goals != nil {
+		return m.goals.EnergyKcal
+	}
+	return 0
+}
+
+// streaks returns the longest run of logged days within the month and the
+// run ending on the month's last elapsed day. An unlogged today doesn't break
+// the current streak, as the day isn't over yet.
+func (m monthModel) streaks() (longest, current int) {
+	end := m.month.AddDate(0, 1, -1)
+	today := dayStart(time.Now())
+	if end.After(today) {
+		end = today
+	}
+
+	run := 0
+	for d := m.month; !d.After(end); d = d.AddDate(0, 0, 1) {
+		if m.logged(d) {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+
+	d := end
+	if d.Equal(today) && !m.logged(d) {
+		d = d.AddDate(0, 0, -1)
+	}
+	for lookback := m.month.AddDate(0, 0, -streakLookback); !d.Before(lookback) && m.logged(d); d = d.AddDate(0, 0, -1) {
+		current++
+	}
+	return longest, current
+}
+
+func (m monthModel) View() string {
+	var sb strings.Builder
+
+	sb.WriteString(styleHeader.Render(m.month.Format("January 2006")))
+	sb.WriteString("\n")
+
+	if m.loading {
+		sb.WriteString(styleDimmed.Render("  Loading...") + "\n\n")
+	} else if m.err != "" {
+		sb.WriteString(styleError.Render("  Error: "+m.err) + "\n\n")
+	}
+
+	sb.WriteString(styleDimmed.Render("  Mon  Tue  Wed  Thu  Fri  Sat  Sun") + "\n")
+
+	today := dayStart(time.Now())
+	last := m.month.AddDate(0, 1, -1)
+	for week := weekStart(m.month); !week.After(last); week = week.AddDate(0, 0, 7) {
+		sb.WriteString(" ")
+		for i := 0; i < 7; i++ {
+			d := week.AddDate(0, 0, i)
+			sb.WriteString(" ")
+			if d.Month() != m.month.Month() {
+				sb.WriteString("    ")
+				continue
+			}
+			sb.WriteString(m.cell(d, today))
+		}
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
+
+	// Details of the selected day
+	sel := m.day(m.selected)
+	goal := m.energyGoal(m.selected)
+	detail := fmt.Sprintf("  %s  ", m.selected.Format("Mon Jan 2"))
+	switch {
+	case m.loading:
+	case !m.logged(m.selected):
+		detail += styleDimmed.Render("nothing logged")
+	case goal > 0:
+		detail += lipgloss.NewStyle().Foreground(goalColor(sel.Energy, goal)).
+			Render(fmt.Sprintf("%.0f / %.0f kcal (%.0f%%)", sel.Energy, goal, sel.Energy/goal*100))
+	default:
+		detail += fmt.Sprintf("%.0f kcal", sel.Energy)
+	}
+	sb.WriteString(detail + "\n")
+
+	if !m.loading && m.err == "" {
+		days, logged := 0, 0
+		for d := m.month; !d.After(last) && !d.After(today); d = d.AddDate(0, 0, 1) {
+			days++
+			if m.logged(d) {
+				logged++
+			}
+		}
+		longest, current := m.streaks()
+		sb.WriteString(styleDimmed.Render(fmt.Sprintf("  Logged %d of %d days · longest streak %d · current streak %d",
+			logged, days, longest, current)) + "\n")
+	}
+
+	sb.WriteString("\n  ")
+	for _, l := range []struct {
+		value float64
+		label string
+	}{{0, "none"}, {0.5, "<90%"}, {1, "90–110%"}, {1.5, ">110%"}} {
+		sb.WriteString(lipgloss.NewStyle().Background(heatColor(l.value, 1)).Render("  ") + " " + styleDimmed.Render(l.label) + "  ")
+	}
+	sb.WriteString("\n")
+
+	sb.WriteString(styleHelp.Render("[←/→] day  [↑/↓] week  [ / ] month  [Enter] open day  [t] today  [Esc] back"))
+	return sb.String()
+}
+
+// cell renders one day of the calendar, tinted by its calories.
+func (m monthModel) cell(d, today time.Time) string {
+	label := fmt.Sprintf(" %2d ", d.Day())
+	style := lipgloss.NewStyle()
+	switch {
+	case d.After(today) || m.loading:
+		style = style.Foreground(colorMuted)
+	case !m.logged(d):
+		style = style.Background(colorSubtle).Foreground(colorMuted)
+	default:
+		style = style.Background(heatColor(m.day(d).Energy, m.energyGoal(d))).Foreground(colorBg)
+	}
+	if d.Equal(m.selected) {
+		label = fmt.Sprintf("[%2d]", d.Day())
+		style = style.Bold(true)
+	}
+	return style.Render(label)
+}
+
+// heatColor is the calendar tint for a day's calories relative to its goal,
+// with the same thresholds as goalColor.
+func heatColor(value, goal float64) lipgloss.Color {
+	if value <= 0 {
+		return colorSubtle
+	}
+	return goalColor(value, goal)
+}
@@ -0,0 +1,47 @@
+package tui
+
+import (
+	"testing"
+	"time"
+
+	"github.com/koriwi/yazio-cli/internal/api"
+)
+
+func TestAddMonths(t *testing.T) {
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.Local) }
+	tests := []struct {
+		from time.Time
+		n    int
+		want time.Time
+	}{
+		{day(2026, 3, 31), -1, day(2026, 2, 28)},
+		{day(2026, 3, 29), -1, day(2026, 2, 28)},
+		{day(2024, 3, 30), -1, day(2024, 2, 29)},
+		{day(2026, 1, 31), 1, day(2026, 2, 28)},
+		{day(2024, 1, 29), 1, day(2024, 2, 29)},
+		{day(2026, 5, 31), 1, day(2026, 6, 30)},
+		{day(2026, 1, 15), -1, day(2025, 12, 15)},
+		{day(2025, 12, 31), 1, day(2026, 1, 31)},
+		{day(2026, 2, 28), 1, day(2026, 3, 28)},
+	}
+	for _, tt := range tests {
+		if got := addMonths(tt.from, tt.n); !got.Equal(tt.want) {
+			t.Errorf("addMonths(%s, %d) = %s, want %s", tt.from.Format(time.DateOnly), tt.n,
+				got.Format(time.DateOnly), tt.want.Format(time.DateOnly))
+		}
+	}
+}
+
+func TestMonthNavigation(t *testing.T) {
+	m := newMonthModel(api.New("token"), time.Date(2024, 3, 31, 0, 0, 0, 0, time.Local))
+	m, _ = m.Update(key("["))
+	if got := m.selected.Format(time.DateOnly); got != "2024-02-29" || !m.month.Equal(monthStart(m.selected)) {
+		t.Errorf("[ from 2024-03-31: selected %s, month %s", got, m.month.Format(time.DateOnly))
+	}
+
+	m = newMonthModel(api.New("token"), time.Date(2024, 1, 31, 0, 0, 0, 0, time.Local))
+	m, _ = m.Update(key("]"))
+	if got := m.selected.Format(time.DateOnly); got != "2024-02-29" || !m.month.Equal(monthStart(m.selected)) {
+		t.Errorf("] from 2024-01-31: selected %s, month %s", got, m.month.Format(time.DateOnly))
+	}
+}
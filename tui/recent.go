@@ -0,0 +1,234 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/koriwi/yazio-cli/internal/api"
+	"github.com/koriwi/yazio-cli/internal/diary"
+	"github.com/koriwi/yazio-cli/internal/models"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// defaultRecentDays is how far back the Recent tab looks; YAZIO_RECENT_DAYS
+	// overrides it.
+	defaultRecentDays = 14
+	maxRecentDays     = 365
+
+	// maxRecentItems is the number of items shown per meal filter.
+	maxRecentItems = 30
+
+	// recentHalfLife is the age in days at which a logged item counts half as
+	// much as one logged today when ranking.
+	recentHalfLife = 7.0
+)
+
+// recentDays returns the Recent tab's window length in days.
+func recentDays() int {
+	if v, err := strconv.Atoi(os.Getenv("YAZIO_RECENT_DAYS")); err == nil && v > 0 {
+		return min(v, maxRecentDays)
+	}
+	return defaultRecentDays
+}
+
+// recentKey identifies a product or a recipe; they have separate ID spaces.
+type recentKey struct {
+	id     string
+	recipe bool
+}
+
+// recentLog is one time an item was logged in the diary.
+type recentLog struct {
+	key      recentKey
+	day      time.Time
+	mealTime string
+}
+
+// recentItem is a row of the Recent tab.
+type recentItem struct {
+	key     recentKey
+	product models.ProductResponse
+	count   int       // times logged in the window
+	last    time.Time // latest day it was logged
+	score   float64
+}
+
+type recentLoadedMsg struct {
+	logs    []recentLog
+	details map[recentKey]*models.ProductResponse
+	err     string
+}
+
+// fetchRecentLogs collects everything logged in the days days up to end. A
+// single range request finds the days that have entries, and those days are
+// then fetched in parallel.
+func fetchRecentLogs(client *api.Client, end time.Time, days int) ([]recentLog, error) {
+	start := end.AddDate(0, 0, -(days - 1))
+	dates, err := diary.LoggedDays(client, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	perDay := make([][]recentLog, len(dates))
+	var g errgroup.Group
+	g.SetLimit(diary.MaxConcurrentFetches)
+	for i, d := range dates {
+		g.Go(func() error {
+			consumed, err := client.GetConsumedItems(d)
+			if err != nil {
+				if errors.Is(err, api.ErrSessionExpired) {
+					return err
+				}
+				return nil // a missing day only makes the ranking less complete
+			}
+			for _, cp := range consumed.Products {
+				perDay[i] = append(perDay[i], recentLog{recentKey{cp.ProductID, false}, d, cp.Daytime})
+			}
+			for _, cr := range consumed.RecipePortions {
+				perDay[i] = append(perDay[i], recentLog{recentKey{cr.RecipeID, true}, d, cr.Daytime})
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	var logs []recentLog
+	for _, l := range perDay {
+		logs = append(logs, l...)
+	}
+	return logs, nil
+}
+
+// rankRecent ranks the logged items by frequency and recency: every time an
+// item was logged adds a weight that halves every recentHalfLife days. With a
+// non-empty mealTime only logs at that meal time count.
+func rankRecent(logs []recentLog, mealTime string, now time.Time) []recentItem {
+	byKey := map[recentKey]*recentItem{}
+	today := dayStart(now)
+	for _, l := range logs {
+		if mealTime != "" && l.mealTime != mealTime {
+			continue
+		}
+		it := byKey[l.key]
+		if it == nil {
+			it = &recentItem{key: l.key}
+			byKey[l.key] = it
+		}
+		age := today.Sub(dayStart(l.day)).Hours() / 24
+		it.score += math.Pow(0.5, math.Max(age, 0)/recentHalfLife)
+		it.count++
+		if l.day.After(it.last) {
+			it.last = l.day
+		}
+	}
+
+	items := make([]recentItem, 0, len(byKey))
+	for _, it := range byKey {
+		items = append(items, *it)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if a.score != b.score {
+			return a.score > b.score
+		}
+		if !a.last.Equal(b.last) {
+			return a.last.After(b.last)
+		}
+		return a.key.id < b.key.id
+	})
+	return items
+}
+
+// recentCandidates returns the items that can appear in the Recent tab under
+// any meal filter, so their details can be loaded up front.
+func recentCandidates(logs []recentLog, now time.Time) []recentKey {
+	seen := map[recentKey]bool{}
+	var keys []recentKey
+	for _, mt := range append([]string{""}, models.MealTimes...) {
+		ranked := rankRecent(logs, mt, now)
+		for _, it := range ranked[:min(len(ranked), maxRecentItems)] {
+			if !seen[it.key] {
+				seen[it.key] = true
+				keys = append(keys, it.key)
+			}
+		}
+	}
+	return keys
+}
+
+// fetchRecentDetails loads the products and recipes for keys. Items that fail
+// to load are left out.
+func fetchRecentDetails(keys []recentKey, client *api.Client, cache *sync.Map) (map[recentKey]*models.ProductResponse, error) {
+	details := make([]*models.ProductResponse, len(keys))
+	var g errgroup.Group
+	g.SetLimit(diary.MaxConcurrentFetches)
+	for i, k := range keys {
+		g.Go(func() error {
+			var p *models.ProductResponse
+			var err error
+			if k.recipe {
+				p, err = diary.FetchRecipe(k.id, client, cache)
+			} else {
+				p, err = diary.FetchProduct(k.id, client, cache)
+			}
+			if errors.Is(err, api.ErrSessionExpired) {
+				return err
+			}
+			details[i] = p
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	out := map[recentKey]*models.ProductResponse{}
+	for i, k := range keys {
+		if details[i] != nil {
+			out[k] = details[i]
+		}
+	}
+	return out, nil
+}
+
+// recentItems ranks the logs for mealTime and attaches the loaded details.
+func recentItems(logs []recentLog, details map[recentKey]*models.ProductResponse, mealTime string, now time.Time) []recentItem {
+	var items []recentItem
+	for _, it := range rankRecent(logs, mealTime, now) {
+		p, ok := details[it.key]
+		if !ok {
+			continue
+		}
+		it.product = *p
+		items = append(items, it)
+		if len(items) == maxRecentItems {
+			break
+		}
+	}
+	return items
+}
+
+func dayStart(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// lastEatenLabel describes day relative to now, e.g. "today" or "3d ago".
+func lastEatenLabel(day, now time.Time) string {
+	days := int(math.Round(dayStart(now).Sub(dayStart(day)).Hours() / 24))
+	switch {
+	case days <= 0:
+		return "today"
+	case days == 1:
+		return "yesterday"
+	}
+	return fmt.Sprintf("%dd ago", days)
+}
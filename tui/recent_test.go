@@ -0,0 +1,95 @@
+package tui
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRankRecent(t *testing.T) {
+	now := time.Date(2024, 1, 15, 18, 30, 0, 0, time.Local)
+	day := func(ago int) time.Time { return time.Date(2024, 1, 15-ago, 0, 0, 0, 0, time.Local) }
+	oats := recentKey{id: "oats"}
+	banana := recentKey{id: "banana"}
+	chili := recentKey{id: "chili", recipe: true}
+	chiliProduct := recentKey{id: "chili"} // same ID, different item
+	logs := []recentLog{
+		{oats, day(0), "breakfast"},
+		{oats, day(1), "breakfast"},
+		{oats, day(2), "breakfast"},
+		{banana, day(0), "snack"},
+		{banana, day(0), "breakfast"},
+		{chili, day(7), "dinner"},
+		{chili, day(7), "dinner"},
+		{chiliProduct, day(14), "dinner"},
+	}
+
+	items := rankRecent(logs, "", now)
+	var got []recentKey
+	for _, it := range items {
+		got = append(got, it.key)
+	}
+	want := []recentKey{oats, banana, chili, chiliProduct}
+	if len(got) != len(want) {
+		t.Fatalf("ranked %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("ranked %v, want %v", got, want)
+		}
+	}
+
+	if it := items[0]; it.count != 3 || !it.last.Equal(day(0)) {
+		t.Errorf("oats: count %d, last %s", it.count, it.last)
+	}
+	// Two logs a half-life ago weigh as much as one today
+	if it := items[2]; it.count != 2 || it.score != 1 {
+		t.Errorf("chili: count %d, score %g, want 2 and 1", it.count, it.score)
+	}
+	if it := items[3]; it.score != 0.25 {
+		t.Errorf("two half-lives ago: score %g, want 0.25", it.score)
+	}
+
+	// A meal filter only counts logs at that meal
+	items = rankRecent(logs, "snack", now)
+	if len(items) != 1 || items[0].key != banana || items[0].count != 1 {
+		t.Errorf("snack: %+v, want banana once", items)
+	}
+	if items := rankRecent(logs, "lunch", now); len(items) != 0 {
+		t.Errorf("lunch: %+v, want nothing", items)
+	}
+}
+
+func TestRankRecentTies(t *testing.T) {
+	now := time.Date(2024, 1, 15, 0, 0, 0, 0, time.Local)
+	logs := []recentLog{
+		{recentKey{id: "b"}, now, "lunch"},
+		{recentKey{id: "a"}, now, "lunch"},
+		{recentKey{id: "c"}, now.AddDate(0, 0, 1), "lunch"}, // logged ahead counts as today
+	}
+	items := rankRecent(logs, "", now)
+	// Equal scores go to the latest day first, then by ID
+	if len(items) != 3 || items[0].key.id != "c" || items[1].key.id != "a" || items[2].key.id != "b" {
+		t.Errorf("order = %+v, want c, a, b", items)
+	}
+	if items[0].score != 1 {
+		t.Errorf("future log: score %g, want 1", items[0].score)
+	}
+}
+
+func TestLastEatenLabel(t *testing.T) {
+	now := time.Date(2024, 1, 15, 9, 0, 0, 0, time.Local)
+	tests := []struct {
+		day  time.Time
+		want string
+	}{
+		{time.Date(2024, 1, 15, 0, 0, 0, 0, time.Local), "today"},
+		{time.Date(2024, 1, 16, 0, 0, 0, 0, time.Local), "today"},
+		{time.Date(2024, 1, 14, 23, 0, 0, 0, time.Local), "yesterday"},
+		{time.Date(2024, 1, 5, 0, 0, 0, 0, time.Local), "10d ago"},
+	}
+	for _, tt := range tests {
+		if got := lastEatenLabel(tt.day, now); got != tt.want {
+			t.Errorf("lastEatenLabel(%s) = %q, want %q", tt.day.Format(time.DateOnly), got, tt.want)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import "github.com/charmbracelet/lipgloss"
 var (
 	colorPrimary  = lipgloss.Color("#F97316") // orange
 	colorSuccess  = lipgloss.Color("#22C55E") // green
+	colorError    = lipgloss.Color("#EF4444") // red
 	colorMuted    = lipgloss.Color("#6B7280") // gray
 	colorSubtle   = lipgloss.Color("#374151") // dark gray
 	colorBg       = lipgloss.Color("#111827") // very dark
@@ -56,7 +57,7 @@ var (
 			MarginTop(1)
 
 	styleError = lipgloss.NewStyle().
-			Foreground(lipgloss.Color("#EF4444")).
+			Foreground(colorError).
 			Bold(true)
 
 	styleSelected = lipgloss.NewStyle().
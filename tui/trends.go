@@ -0,0 +1,278 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/koriwi/yazio-cli/internal/api"
+	"github.com/koriwi/yazio-cli/internal/models"
+	"golang.org/x/sync/errgroup"
+)
+
+// trendSpans are the selectable time ranges in days, zoomed with +/-.
+var trendSpans = []int{30, 90, 365}
+
+type trendMetric struct {
+	label string
+	unit  string
+	color lipgloss.Color
+	value func(models.DailyNutrient) float64
+	goal  func(models.GoalsResponse) float64
+}
+
+var trendMetrics = []trendMetric{
+	{"Calories", "kcal", colorCalories,
+		func(d models.DailyNutrient) float64 { return d.Energy },
+		func(g models.GoalsResponse) float64 { return g.EnergyKcal }},
+	{"Protein", "g", colorProtein,
+		func(d models.DailyNutrient) float64 { return d.Protein },
+		func(g models.GoalsResponse) float64 { return g.Protein }},
+	{"Carbs", "g", colorCarbs,
+		func(d models.DailyNutrient) float64 { return d.Carb },
+		func(g models.GoalsResponse) float64 { return g.Carb }},
+	{"Fat", "g", colorFat,
+		func(d models.DailyNutrient) float64 { return d.Fat },
+		func(g models.GoalsResponse) float64 { return g.Fat }},
+}
+
+// trendsModel charts daily calories and macros over a range of days.
+type trendsModel struct {
+	client   *api.Client
+	end      time.Time // last day shown
+	span     int       // index into trendSpans
+	metric   int       // index into trendMetrics
+	days     map[string]models.DailyNutrient
+	goals    *models.GoalsResponse
+	from     time.Time // every day from here to today has been fetched
+	fetching bool
+	err      string
+	width    int
+	height   int
+}
+
+type trendsLoadedMsg struct {
+	from, to time.Time
+	days     []models.DailyNutrient
+	goals    *models.GoalsResponse
+	err      string
+}
+
+func newTrendsModel(client *api.Client, date time.Time) trendsModel {
+	return trendsModel{
+		client: client,
+		end:    dayStart(date),
+		days:   map[string]models.DailyNutrient{},
+	}
+}
+
+// averageWindow is the moving average length in days for the current span.
+func (m trendsModel) averageWindow() int {
+	if trendSpans[m.span] >= 365 {
+		return 30
+	}
+	return 7
+}
+
+// start returns the first day shown.
+func (m trendsModel) start() time.Time {
+	return m.end.AddDate(0, 0, -(trendSpans[m.span] - 1))
+}
+
+// ensureLoaded fetches the days the chart needs (including the moving
+// average's warm-up) that haven't been fetched yet. The first load fetches
+// the largest span at once so zooming out doesn't need another request.
+func (m trendsModel) ensureLoaded() (trendsModel, tea.Cmd) {
+	if m.fetching {
+		return m, nil // checked again when the load finishes
+	}
+	need := m.start().AddDate(0, 0, -m.averageWindow())
+	if !m.from.IsZero() && !need.Before(m.from) {
+		return m, nil
+	}
+
+	var from, to time.Time
+	if m.from.IsZero() {
+		to = dayStart(time.Now())
+		from = to.AddDate(0, 0, -(trendSpans[len(trendSpans)-1] + 30))
+		if need.Before(from) {
+			from = need
+		}
+	} else {
+		to = m.from.AddDate(0, 0, -1)
+		from = need
+	}
+	m.fetching = true
+	m.err = ""
+
+	client := m.client
+	needGoals := m.goals == nil
+	return m, func() tea.Msg {
+		var days []models.DailyNutrient
+		var goals *models.GoalsResponse
+		var g errgroup.Group
+		g.Go(func() (err error) {
+			days, err = client.GetDailyNutrientsRange(from, to)
+			return err
+		})
+		if needGoals {
+			g.Go(func() (err error) {
+				goals, err = client.GetGoals(to)
+				return err
+			})
+		}
+		if err := g.Wait(); err != nil {
+			if errors.Is(err, api.ErrSessionExpired) {
+				return sessionExpiredMsg{}
+			}
+			return trendsLoadedMsg{from: from, to: to, err: err.Error()}
+		}
+		return trendsLoadedMsg{from: from, to: to, days: days, goals: goals}
+	}
+}
+
+func (m trendsModel) Update(msg tea.Msg) (trendsModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+
+	case trendsLoadedMsg:
+		m.fetching = false
+		if msg.err != "" {
+			m.err = msg.err
+			return m, nil
+		}
+		for _, d := range msg.days {
+			m.days[d.Date] = d
+		}
+		if msg.goals != nil {
+			m.goals = msg.goals
+		}
+		if m.from.IsZero() || msg.from.Before(m.from) {
+			m.from = msg.from
+		}
+		// The user may have panned further while this was loading
+		return m.ensureLoaded()
+
+	case tea.KeyMsg:
+		step := max(1, trendSpans[m.span]/4)
+		switch msg.String() {
+		case "esc", "q", "T":
+			return m, func() tea.Msg { return backToDiaryMsg{} }
+		case "left", "h":
+			m.end = m.end.AddDate(0, 0, -step)
+			return m.ensureLoaded()
+		case "right", "l":
+			m.end = m.end.AddDate(0, 0, step)
+			if today := dayStart(time.Now()); m.end.After(today) {
+				m.end = today
+			}
+		case "+", "=":
+			m.span = max(0, m.span-1)
+		case "-":
+			m.span = min(len(trendSpans)-1, m.span+1)
+			return m.ensureLoaded()
+		case "tab", "j", "down":
+			m.metric = (m.metric + 1) % len(trendMetrics)
+		case "shift+tab", "k", "up":
+			m.metric = (m.metric + len(trendMetrics) - 1) % len(trendMetrics)
+		case "t":
+			m.end = dayStart(time.Now())
+		}
+	}
+	return m, nil
+}
+
+// series returns metric's daily values from start to m.end, with NaN for
+// days without any entries.
+func (m trendsModel) series(metric trendMetric, start time.Time) []float64 {
+	var out []float64
+	for d := start; !d.After(m.end); d = d.AddDate(0, 0, 1) {
+		day, ok := m.days[d.Format(time.DateOnly)]
+		if !ok || day.Energy <= 0 {
+			out = append(out, math.NaN())
+			continue
+		}
+		out = append(out, metric.value(day))
+	}
+	return out
+}
+
+func (m trendsModel) View() string {
+	var sb strings.Builder
+
+	span := trendSpans[m.span]
+	start := m.start()
+	metric := trendMetrics[m.metric]
+	title := fmt.Sprintf("Trends · %s · %d days", metric.label, span)
+	sb.WriteString(styleHeader.Render(title))
+	sb.WriteString("\n")
+
+	if m.err != "" {
+		sb.WriteString(styleError.Render("  Error: "+m.err) + "\n\n")
+	} else if m.fetching && len(m.days) == 0 {
+		sb.WriteString(styleDimmed.Render("  Loading...") + "\n")
+		sb.WriteString(styleHelp.Render("[Esc] back"))
+		return sb.String()
+	}
+
+	var goals models.GoalsResponse
+	if m.goals != nil {
+		goals = *m.goals
+	}
+
+	availW := m.width
+	if availW < 40 {
+		availW = 80
+	}
+	cols := availW - 14
+	window := m.averageWindow()
+
+	// Daily values with the moving average aligned to the same days, both
+	// averaged down to the chart width
+	warm := m.series(metric, start.AddDate(0, 0, -(window-1)))
+	daily := warm[window-1:]
+	avg := movingAverage(warm, window)
+	chartH := max(6, m.height-16)
+	sb.WriteString(barChart(stretch(bucket(daily, cols), cols), stretch(bucket(avg, cols), cols), metric.goal(goals), chartH, metric.unit,
+		start.Format("Jan 2"), m.end.Format("Jan 2, 2006"), metric.color))
+	sb.WriteString("\n")
+
+	legend := lipgloss.NewStyle().Foreground(metric.color).Render("█") + styleDimmed.Render(" daily  ") +
+		lipgloss.NewStyle().Foreground(colorText).Bold(true).Render("•") + styleDimmed.Render(fmt.Sprintf(" %d-day average  ", window)) +
+		styleDimmed.Render("┄ goal")
+	sb.WriteString("  " + legend + "\n\n")
+
+	// All metrics as sparklines, the selected one highlighted
+	sparkW := availW - 40
+	for i, mt := range trendMetrics {
+		values := m.series(mt, start)
+		mean, logged := 0.0, 0
+		top := mt.goal(goals)
+		for _, v := range values {
+			if !math.IsNaN(v) {
+				mean += v
+				logged++
+				top = math.Max(top, v)
+			}
+		}
+		summary := "no data"
+		if logged > 0 {
+			summary = fmt.Sprintf("avg %.0f %s", mean/float64(logged), mt.unit)
+		}
+		label := fmt.Sprintf("  %-9s", mt.label)
+		if i == m.metric {
+			label = styleSelected.Render(label)
+		} else {
+			label = styleDimmed.Render(label)
+		}
+		sb.WriteString(fmt.Sprintf("%s %s  %s\n", label, sparkline(bucket(values, sparkW), top, mt.color), styleDimmed.Render(summary)))
+	}
+
+	sb.WriteString(styleHelp.Render("[←/→] pan  [+/-] zoom 30/90/365 days  [↑/↓] metric  [t] today  [Esc] back"))
+	return sb.String()
+}
@@ -0,0 +1,276 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/koriwi/yazio-cli/internal/api"
+	"github.com/koriwi/yazio-cli/internal/models"
+	"golang.org/x/sync/errgroup"
+)
+
+// weekModel shows the daily totals of one week (Monday to Sunday) against
+// the goals.
+type weekModel struct {
+	client   *api.Client
+	start    time.Time // Monday of the week shown
+	days     [7]models.DailyNutrient
+	goals    *models.GoalsResponse
+	selected int // 0 = Monday
+	loading  bool
+	err      string
+	width    int
+	height   int
+}
+
+type weekLoadedMsg struct {
+	start time.Time
+	days  []models.DailyNutrient
+	goals *models.GoalsResponse
+	err   string
+}
+
+// openDayMsg asks the app to show date in the diary.
+type openDayMsg struct{ date time.Time }
+
+func newWeekModel(client *api.Client, date time.Time) weekModel {
+	start := weekStart(date)
+	return weekModel{
+		client:   client,
+		start:    start,
+		selected: dayIndex(start, date),
+		loading:  true,
+	}
+}
+
+// weekStart returns midnight of the Monday on or before t.
+func weekStart(t time.Time) time.Time {
+	d := dayStart(t)
+	offset := (int(d.Weekday()) + 6) % 7 // Monday = 0
+	return d.AddDate(0, 0, -offset)
+}
+
+// dayIndex returns the number of calendar days from start to t. It rounds
+// so that days lengthened or shortened by DST still count as one.
+func dayIndex(start, t time.Time) int {
+	return int(math.Round(dayStart(t).Sub(dayStart(start)).Hours() / 24))
+}
+
+func (m weekModel) load() tea.Cmd {
+	client := m.client
+	start := m.start
+	end := start.AddDate(0, 0, 6)
+
+	return func() tea.Msg {
+		var days []models.DailyNutrient
+		var goals *models.GoalsResponse
+		var g errgroup.Group
+		g.Go(func() (err error) {
+			days, err = client.GetDailyNutrientsRange(start, end)
+			return err
+		})
+		g.Go(func() (err error) {
+			goals, err = client.GetGoals(end)
+			return err
+		})
+		if err := g.Wait(); err != nil {
+			if errors.Is(err, api.ErrSessionExpired) {
+				return sessionExpiredMsg{}
+			}
+			return weekLoadedMsg{start: start, err: err.Error()}
+		}
+		return weekLoadedMsg{start: start, days: days, goals: goals}
+	}
+}
+
+func (m weekModel) date(i int) time.Time {
+	return m.start.AddDate(0, 0, i)
+}
+
+// goTo switches to the week starting at start and loads it. Weeks in the
+// future are ignored.
+func (m weekModel) goTo(start time.Time) (weekModel, tea.Cmd) {
+	if start.After(time.Now()) || start.Equal(m.start) {
+		return m, nil
+	}
+	m.start = start
+	m.days = [7]models.DailyNutrient{}
+	m.loading = true
+	m.err = ""
+	return m, m.load()
+}
+
+func (m weekModel) Update(msg tea.Msg) (weekModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+
+	case weekLoadedMsg:
+		if !msg.start.Equal(m.start) {
+			return m, nil // a week we already navigated away from
+		}
+		m.loading = false
+		if msg.err != "" {
+			m.err = msg.err
+			break
+		}
+		m.goals = msg.goals
+		for _, d := range msg.days {
+			day, err := time.ParseInLocation(time.DateOnly, d.Date, m.start.Location())
+			if err != nil {
+				continue
+			}
+			if i := dayIndex(m.start, day); i >= 0 && i < 7 {
+				m.days[i] = d
+			}
+		}
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "esc", "q", "w":
+			return m, func() tea.Msg { return backToDiaryMsg{} }
+		case "left", "h":
+			return m.goTo(m.start.AddDate(0, 0, -7))
+		case "right", "l":
+			return m.goTo(m.start.AddDate(0, 0, 7))
+		case "t":
+			now := time.Now()
+			m.selected = dayIndex(weekStart(now), now)
+			return m.goTo(weekStart(now))
+		case "j", "down":
+			if m.selected < 6 {
+				m.selected++
+			}
+		case "k", "up":
+			if m.selected > 0 {
+				m.selected--
+			}
+		case "enter":
+			date := m.date(m.selected)
+			if !date.After(time.Now()) {
+				return m, func() tea.Msg { return openDayMsg{date: date} }
+			}
+		}
+	}
+	return m, nil
+}
+
+// energyGoal returns the calorie goal of day i: the goal stored with the day
+// if there is one, otherwise the current goal.
+func (m weekModel) energyGoal(i int) float64 {
+	if g := m.days[i].EnergyGoal; g > 0 {
+		return g
+	}
+	if m.goals != nil {
+		return m.goals.EnergyKcal
+	}
+	return 0
+}
+
+func (m weekModel) View() string {
+	var sb strings.Builder
+
+	end := m.start.AddDate(0, 0, 6)
+	title := fmt.Sprintf("Week %s – %s", m.start.Format("Jan 2"), end.Format("Jan 2, 2006"))
+	sb.WriteString(styleHeader.Render(title))
+	sb.WriteString("\n")
+
+	if m.loading {
+		sb.WriteString(styleDimmed.Render("  Loading...") + "\n")
+	} else if m.err != "" {
+		sb.WriteString(styleError.Render("  Error: "+m.err) + "\n")
+	}
+
+	var goals models.GoalsResponse
+	if m.goals != nil {
+		goals = *m.goals
+	}
+
+	barW := 20
+	sb.WriteString(styleDimmed.Render(fmt.Sprintf("  %-10s  %-15s  %-*s  %-9s  %-9s  %-9s",
+		"", "kcal", barW+2, "", "Protein", "Carbs", "Fat")) + "\n")
+
+	var sum models.DailyNutrient
+	logged := 0
+	for i := 0; i < 7; i++ {
+		d := m.days[i]
+		date := m.date(i)
+		goal := m.energyGoal(i)
+		future := date.After(time.Now())
+
+		labelCell := fmt.Sprintf("  %-10s", date.Format("Mon Jan 2"))
+		if i == m.selected {
+			labelCell = styleSelected.Render(labelCell)
+		}
+		var line string
+		if future || m.loading {
+			line = styleDimmed.Render("—")
+		} else {
+			if d.Energy > 0 {
+				sum.Energy += d.Energy
+				sum.Protein += d.Protein
+				sum.Carb += d.Carb
+				sum.Fat += d.Fat
+				logged++
+			}
+			kcal := lipgloss.NewStyle().Foreground(goalColor(d.Energy, goal)).
+				Render(padRight(fmt.Sprintf("%.0f / %.0f", d.Energy, goal), 15))
+			line = fmt.Sprintf("%s  [%s]  %s  %s  %s",
+				kcal,
+				miniBar(d.Energy, goal, barW, goalColor(d.Energy, goal)),
+				macroCell(d.Protein, goals.Protein),
+				macroCell(d.Carb, goals.Carb),
+				macroCell(d.Fat, goals.Fat),
+			)
+		}
+		sb.WriteString(labelCell + "  " + line + "\n")
+	}
+
+	if logged > 0 {
+		n := float64(logged)
+		sb.WriteString("\n")
+		sb.WriteString(styleDimmed.Render(fmt.Sprintf("  Average over %d logged day(s): %.0f kcal · P %.0fg · C %.0fg · F %.0fg",
+			logged, sum.Energy/n, sum.Protein/n, sum.Carb/n, sum.Fat/n)) + "\n")
+		sb.WriteString(styleDimmed.Render(fmt.Sprintf("  Total: %.0f kcal", sum.Energy)) + "\n")
+	}
+
+	sb.WriteString(styleHelp.Render("[←/→] week  [↑/↓] day  [Enter] open day  [t] this week  [Esc] back"))
+	return sb.String()
+}
+
+// goalColor colors a value by how it compares to its goal: green within 10%,
+// red above, amber below and gray when nothing was logged.
+func goalColor(value, goal float64) lipgloss.Color {
+	switch {
+	case value <= 0:
+		return colorMuted
+	case goal <= 0:
+		return colorText
+	case value > goal*1.1:
+		return colorError
+	case value >= goal*0.9:
+		return colorSuccess
+	}
+	return colorCarbs
+}
+
+// miniBar renders value/goal as a bar of width cells, capped at full.
+func miniBar(value, goal float64, width int, color lipgloss.Color) string {
+	filled := 0
+	if goal > 0 {
+		filled = min(width, int(value/goal*float64(width)))
+	}
+	return lipgloss.NewStyle().Foreground(color).Render(strings.Repeat("█", filled)) +
+		lipgloss.NewStyle().Foreground(colorSubtle).Render(strings.Repeat("░", width-filled))
+}
+
+// macroCell renders a macro total in grams, colored against its goal.
+func macroCell(value, goal float64) string {
+	return lipgloss.NewStyle().Foreground(goalColor(value, goal)).
+		Render(padRight(fmt.Sprintf("%.0f/%.0fg", value, goal), 9))
+}